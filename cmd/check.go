@@ -0,0 +1,74 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/retr0h/gofile/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verify bool
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the binaries of gofile packages",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := pkg.Load(fileName, pkg.WithLogger(utils.Log), pkg.WithStateFile(stateFile))
+		if err != nil {
+			msg := fmt.Sprintf("An error occurred unmarshalling '%s'.\n%s\n", fileName, err)
+			utils.PrintErrorAndExit(msg, exitCode(err))
+		}
+
+		results, err := p.Check(verify)
+		if err != nil {
+			msg := fmt.Sprintf("An error occurred checking packages.\n%s\n", err)
+			utils.PrintErrorAndExit(msg, exitCode(err))
+		}
+
+		if err := pkg.PrintCheck(os.Stdout, results, output); err != nil {
+			return err
+		}
+
+		var modified bool
+		for _, result := range results {
+			modified = modified || result.Modified
+		}
+
+		if modified {
+			utils.PrintErrorAndExit("Binaries were modified since they were installed.\n", exitModified)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	checkCmd.PersistentFlags().StringVarP(&fileName, "filename", "f", "gofile.yml", "Path to gofile")
+	checkCmd.PersistentFlags().BoolVar(&verify, "verify", false, "Re-hash binaries to detect modifications since they were installed")
+	rootCmd.AddCommand(checkCmd)
+}
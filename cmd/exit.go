@@ -0,0 +1,109 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"syscall"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of gofile, documented in the README.
+const (
+	exitError       = 1   // Unexpected errors.
+	exitUsage       = 2   // Invalid flags or arguments.
+	exitManifest    = 3   // The gofile cannot be read, or is invalid.
+	exitToolchain   = 4   // The go toolchain requested is not installed.
+	exitInstall     = 5   // A package failed to install.
+	exitNetwork     = 6   // A package failed to install with a transient network error.
+	exitModified    = 7   // Binaries were modified since they were installed.
+	exitInterrupted = 130 // Interrupted by SIGINT or SIGTERM.
+)
+
+// usageError is returned for invalid flags or arguments.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *usageError) Unwrap() error {
+	return e.err
+}
+
+// usageArgs returns a validator of positional arguments running the provided
+// validators in order, with their errors reported as usage errors.
+func usageArgs(validators ...cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		for _, validate := range validators {
+			if err := validate(cmd, args); err != nil {
+				return &usageError{err: err}
+			}
+		}
+
+		return nil
+	}
+}
+
+// exitCode returns the exit code describing the provided error.
+func exitCode(err error) int {
+	var (
+		usageErr      *usageError
+		manifestErr   *pkg.ManifestError
+		validationErr *pkg.ValidationError
+		toolchainErr  *pkg.ToolchainNotFoundError
+		installErr    *pkg.InstallError
+	)
+
+	switch {
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.As(err, &manifestErr), errors.As(err, &validationErr):
+		return exitManifest
+	case errors.As(err, &toolchainErr):
+		return exitToolchain
+	case errors.As(err, &installErr) && installErr.Transient():
+		return exitNetwork
+	case errors.As(err, &installErr):
+		return exitInstall
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	default:
+		return exitError
+	}
+}
+
+// exitStatus returns the exit code of the provided exited process, which is
+// 128 plus the number of the signal when it was killed by one, as shells
+// report it.
+func exitStatus(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return err.ExitCode()
+}
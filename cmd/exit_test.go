@@ -0,0 +1,55 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"unexpected", errors.New("boom"), exitError},
+		{"usage", &usageError{err: errors.New("unknown flag: --bogus")}, exitUsage},
+		{"manifest", &pkg.ManifestError{}, exitManifest},
+		{"validation", &pkg.ValidationError{}, exitManifest},
+		{"toolchain", &pkg.ToolchainNotFoundError{Toolchain: "go1.12"}, exitToolchain},
+		{"install", &pkg.InstallError{Err: errors.New("exit status 1")}, exitInstall},
+		{"network", &pkg.InstallError{Err: &pkg.CommandError{Stderr: "dial tcp: i/o timeout"}}, exitNetwork},
+		{"interrupted", fmt.Errorf("installing: %w", context.Canceled), exitInterrupted},
+		{"wrapped", fmt.Errorf("loading: %w", &pkg.ManifestError{}), exitManifest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exitCode(tt.err))
+		})
+	}
+}
@@ -21,7 +21,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/retr0h/gofile/pkg"
 	"github.com/retr0h/gofile/utils"
@@ -30,6 +37,22 @@ import (
 
 var (
 	fileName string
+	dryRun   bool
+	timeout  time.Duration
+	retries  int
+	isolated bool
+	cacheDir string
+	lock     bool
+	lockFile string
+	offline  bool
+	proxyDir string
+	storeDir string
+	keep     int
+	force    bool
+
+	continueOnError  bool
+	completionDir    string
+	completionShells []string
 )
 
 // installCmd represents the install command
@@ -37,25 +60,111 @@ var installCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install gofile packages",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p := pkg.Packages{
-			Debug: debug,
+		// Progress is redrawn on a terminal when logging at the info level,
+		// with log messages written above it.
+		var term io.Writer
+		if output == pkg.FormatText && utils.Log.Level == utils.LevelInfo && utils.IsTerminal(os.Stdout) {
+			term = os.Stdout
+		}
+		progress := pkg.NewProgress(utils.Log, term)
+		logger := utils.Log
+		if term != nil {
+			logger = utils.Log.WithOut(progress)
 		}
 
-		if err := p.UnmarshalYAMLFile(fileName); err != nil {
+		opts := []pkg.Option{
+			pkg.WithLogger(logger),
+			pkg.WithObserver(progress),
+			pkg.WithObserver(pkg.NewEventWriter(os.Stdout, output)),
+			pkg.WithTimeout(timeout),
+			pkg.WithRetries(retries),
+			pkg.WithStateFile(stateFile),
+			pkg.WithStore(storeDir, keep),
+			pkg.WithForce(force),
+			pkg.WithContinueOnError(continueOnError),
+			pkg.WithCompletions(completionDir, completionShells...),
+		}
+		if isolated {
+			opts = append(opts, pkg.WithIsolated(cacheDir))
+		}
+		// Offline installs trust the lock rather than the checksum database.
+		lockPath := lockFilePath(fileName, lockFile, lock)
+		if offline && lockPath == "" {
+			return &usageError{err: fmt.Errorf("--offline requires --lock or --lockfile")}
+		}
+		if offline {
+			opts = append(opts, pkg.WithOffline(proxyDir))
+		}
+		opts = append(opts, pkg.WithLockFile(lockPath))
+
+		p, err := pkg.Load(fileName, opts...)
+		if err != nil {
 			msg := fmt.Sprintf("An error occurred unmarshalling '%s'.\n%s\n", fileName, err)
-			utils.PrintErrorAndExit(msg)
+			utils.PrintErrorAndExit(msg, exitCode(err))
 		}
 
-		if err := p.Install(); err != nil {
+		if dryRun {
+			steps, err := p.Plan()
+			if err != nil {
+				msg := fmt.Sprintf("An error occurred planning packages.\n%s\n", err)
+				utils.PrintErrorAndExit(msg, exitCode(err))
+			}
+
+			return pkg.PrintPlan(os.Stdout, steps, output)
+		}
+
+		// Cancel the install on SIGINT/SIGTERM, which terminates the running
+		// `go` command along with its children.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if _, err := p.Apply(ctx); err != nil {
 			msg := fmt.Sprintf("An error occurred installing packages.\n%s\n", err)
-			utils.PrintErrorAndExit(msg)
+			utils.PrintErrorAndExit(msg, exitCode(err))
 		}
 
 		return nil
 	},
 }
 
+// lockFilePath returns the lockfile set by --lockfile, or gofile.lock next to
+// the gofile with --lock, and empty when locking is disabled.
+func lockFilePath(gofile string, lockfile string, lock bool) string {
+	if lockfile != "" {
+		return lockfile
+	}
+
+	if lock {
+		return filepath.Join(filepath.Dir(gofile), "gofile.lock")
+	}
+
+	return ""
+}
+
 func init() {
 	installCmd.PersistentFlags().StringVarP(&fileName, "filename", "f", "gofile.yml", "Path to gofile")
+	installCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the install plan without executing it")
+	installCmd.PersistentFlags().IntVar(&retries, "retries", 0, "Retries of a package install failing with a transient network error")
+	installCmd.PersistentFlags().BoolVar(&continueOnError, "continue-on-error", false, "Install the remaining packages when a package or its hooks fail")
+	installCmd.PersistentFlags().BoolVar(&force, "force", false, "Reinstall packages which are up to date")
+	installCmd.PersistentFlags().BoolVar(&isolated, "isolated", false, "Build each package in a throwaway GOPATH, module cache, and build cache")
+	installCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "Directory persisting the module and build caches of isolated builds")
+	installCmd.PersistentFlags().BoolVar(&lock, "lock", false, "Record and verify module hashes in gofile.lock next to the gofile")
+	installCmd.PersistentFlags().StringVar(&lockFile, "lockfile", "", "Path to the lockfile of module hashes, implies --lock")
+	installCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Install modules without network access, from the lockfile and --proxy-dir, requires --lock or --lockfile")
+	installCmd.PersistentFlags().StringVar(&proxyDir, "proxy-dir", "", "Directory of a local module proxy used when --offline")
+
+	// The default is empty when the home directory is unknown, which disables
+	// keeping binaries for rollbacks.
+	defaultStoreDir, _ := pkg.DefaultStoreDir()
+	installCmd.PersistentFlags().StringVar(&storeDir, "store-dir", defaultStoreDir, "Directory keeping installed binaries for rollbacks, linked into the bin directory")
+	installCmd.PersistentFlags().IntVar(&keep, "keep", 3, "Binaries kept per package for rollbacks, including the installed binary")
+
+	// The default is empty when the home directory is unknown, which disables
+	// generating completion scripts.
+	defaultCompletionDir, _ := pkg.DefaultCompletionDir()
+	installCmd.PersistentFlags().StringVar(&completionDir, "completion-dir", defaultCompletionDir, "Directory receiving the completion scripts of packages which set completion, disabled when empty")
+	installCmd.PersistentFlags().StringSliceVar(&completionShells, "completion-shells", pkg.Shells, "Shells completion scripts are generated for")
+	installCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Timeout of each package install (e.g. 5m), no timeout when 0")
 	rootCmd.AddCommand(installCmd)
 }
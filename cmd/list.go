@@ -0,0 +1,49 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/retr0h/gofile/utils"
+	"github.com/spf13/cobra"
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list [binary|url...]",
+	Short: "List binaries installed by gofile",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := pkg.LoadState(stateFile)
+		if err != nil {
+			msg := fmt.Sprintf("An error occurred loading '%s'.\n%s\n", stateFile, err)
+			utils.PrintErrorAndExit(msg, exitCode(err))
+		}
+
+		return pkg.PrintInstalled(os.Stdout, s.List(args...), output)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+}
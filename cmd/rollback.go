@@ -0,0 +1,103 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/retr0h/gofile/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	all bool
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [binary|url...]",
+	Short: "Restore the binaries installed before the last install",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !all {
+			utils.PrintErrorAndExit("Provide the binaries to roll back, or --all.\n", exitUsage)
+		}
+		if len(args) > 0 && all {
+			utils.PrintErrorAndExit("Provide either the binaries to roll back, or --all.\n", exitUsage)
+		}
+
+		s, err := pkg.LoadState(stateFile)
+		if err != nil {
+			msg := fmt.Sprintf("An error occurred loading '%s'.\n%s\n", stateFile, err)
+			utils.PrintErrorAndExit(msg, exitCode(err))
+		}
+
+		list := s.List(args...)
+		if len(list) == 0 {
+			utils.PrintErrorAndExit("No matching binaries were installed by gofile.\n")
+		}
+
+		rolledBack := []*pkg.Installed{}
+		for _, installed := range list {
+			// Binaries installed once have nothing to roll back to.
+			if all && len(installed.Previous) == 0 {
+				continue
+			}
+
+			previous, err := s.Rollback(installed.Binary)
+			if err != nil {
+				msg := fmt.Sprintf("An error occurred rolling back.\n%s\n", err)
+				utils.PrintErrorAndExit(msg, exitCode(err))
+			}
+
+			// Saved after each binary, so the state matches the bin directory
+			// when a later binary fails to roll back.
+			if err := s.Save(stateFile); err != nil {
+				msg := fmt.Sprintf("An error occurred saving '%s'.\n%s\n", stateFile, err)
+				utils.PrintErrorAndExit(msg, exitCode(err))
+			}
+
+			rolledBack = append(rolledBack, previous)
+			utils.Log.Infof("Rolled back: %s to %s\n", utils.Color.Cyan(installed.Binary), rollbackName(previous))
+		}
+
+		if output != pkg.FormatText {
+			return pkg.PrintInstalled(os.Stdout, rolledBack, output)
+		}
+
+		return nil
+	},
+}
+
+// rollbackName returns the package and version of the provided binary.
+func rollbackName(installed *pkg.Installed) string {
+	if installed.Version == "" {
+		return installed.URL
+	}
+
+	return installed.URL + "@" + installed.Version
+}
+
+func init() {
+	rollbackCmd.PersistentFlags().BoolVar(&all, "all", false, "Roll back every binary installed by gofile")
+	rootCmd.AddCommand(rollbackCmd)
+}
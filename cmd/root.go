@@ -24,6 +24,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/retr0h/gofile/pkg"
+	"github.com/retr0h/gofile/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -32,6 +34,13 @@ var (
 	buildHash string
 	buildDate string
 	debug     bool
+	stateFile string
+	output    string
+	logLevel  string
+	logFile   string
+	quiet     bool
+	verbosity int
+	noColor   bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -49,6 +58,60 @@ A simple utility to install go packages from a gofile (gofile.yml).
 
 https://github.com/retr0h/gofile
 `,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		switch output {
+		case pkg.FormatText, pkg.FormatJSON, pkg.FormatNDJSON:
+		default:
+			return &usageError{err: fmt.Errorf("unknown format '%s'", output)}
+		}
+
+		return configureLog()
+	},
+}
+
+// configureLog configures the shared logger from the logging and colour
+// flags.  Logs go to stderr when stdout is reserved for JSON.
+func configureLog() error {
+	level, err := utils.ParseLevel(logLevel)
+	if err != nil {
+		return &usageError{err: err}
+	}
+
+	if quiet && verbosity > 0 {
+		return &usageError{err: fmt.Errorf("--quiet and --verbose are mutually exclusive")}
+	}
+
+	switch {
+	case quiet:
+		level = utils.LevelError
+	case debug:
+		level = utils.LevelTrace
+	case verbosity > 0:
+		level = utils.LevelInfo + utils.Level(verbosity)
+		if level > utils.LevelTrace {
+			level = utils.LevelTrace
+		}
+	}
+	utils.Log.Level = level
+
+	if output != pkg.FormatText {
+		utils.Log.Out = os.Stderr
+		utils.SetColor(utils.ColorEnabled(os.Stderr))
+	}
+
+	if noColor {
+		utils.SetColor(false)
+	}
+
+	if logFile != "" {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		utils.Log.File = f
+	}
+
+	return nil
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -58,12 +121,34 @@ func Execute(v string, bh string, bd string) {
 	buildHash = bh
 	buildDate = bd
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
+		utils.PrintError(err.Error())
+
+		// Only unknown commands fail on the root command itself.
+		if cmd == rootCmd {
+			os.Exit(exitUsage)
+		}
+		os.Exit(exitCode(err))
 	}
 }
 
 func init() {
-	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable or disable debug mode")
+	// Errors are printed by Execute, to stderr.
+	rootCmd.SilenceErrors = true
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &usageError{err: err}
+	})
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable or disable debug mode, an alias of --log-level trace")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", utils.LevelInfo.String(), "Log level (error|warn|info|debug|trace)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Log errors only")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Log commands with -v, and their output with -vv")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colours, which are disabled when not writing to a terminal or NO_COLOR is set")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Append every log message, whatever the log level, to the file")
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", pkg.FormatText, "Output format (text|json|ndjson)")
+
+	// The default is empty when the home directory is unknown, which disables
+	// recording installs.
+	defaultStateFile, _ := pkg.DefaultStateFile()
+	rootCmd.PersistentFlags().StringVar(&stateFile, "state-file", defaultStateFile, "Path to the file recording installed packages")
 }
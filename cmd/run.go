@@ -0,0 +1,123 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/retr0h/gofile/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runFileName string
+	toolsDir    string
+	runLock     bool
+	runLockFile string
+	runForce    bool
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:     "run binary [-- args...]",
+	Aliases: []string{"exec"},
+	Short:   "Run the binary of a gofile package, built at its pinned version",
+	Long: `Run the binary of a gofile package, built at the version pinned by the
+gofile into a cache rather than the bin directory, with the provided args.
+Exits with the exit code of the binary, or 128 plus the number of the signal
+killing it.
+
+  //go:generate gofile run stringer -- -type=Pill
+`,
+	Args: usageArgs(cobra.MinimumNArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Stdout belongs to the binary, so building it is logged to stderr.
+		logger := utils.Log.WithOut(os.Stderr)
+		opts := []pkg.Option{
+			pkg.WithLogger(logger),
+			pkg.WithObserver(pkg.NewProgress(logger, nil)),
+			pkg.WithToolsDir(toolsDir),
+			pkg.WithForce(runForce),
+			pkg.WithLockFile(lockFilePath(runFileName, runLockFile, runLock)),
+		}
+
+		p, err := pkg.Load(runFileName, opts...)
+		if err != nil {
+			msg := fmt.Sprintf("An error occurred unmarshalling '%s'.\n%s\n", runFileName, err)
+			utils.PrintErrorAndExit(msg, exitCode(err))
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		binary, err := p.Tool(ctx, args[0])
+		stop()
+		if err != nil {
+			msg := fmt.Sprintf("An error occurred building '%s'.\n%s\n", args[0], err)
+			utils.PrintErrorAndExit(msg, exitCode(err))
+		}
+
+		// The binary handles interrupts itself, gofile exits once it has.
+		signal.Notify(make(chan os.Signal, 1), os.Interrupt, syscall.SIGTERM)
+
+		// Flags are not parsed after the binary, so the dash separating its
+		// args is optional, and passed through unless stripped.
+		args = args[1:]
+		if len(args) > 0 && args[0] == "--" {
+			args = args[1:]
+		}
+
+		c := exec.Command(binary, args...)
+		c.Stdin = os.Stdin
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				utils.OsExit(exitStatus(exitErr))
+			}
+
+			msg := fmt.Sprintf("An error occurred running '%s'.\n%s\n", binary, err)
+			utils.PrintErrorAndExit(msg, exitError)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	runCmd.Flags().SetInterspersed(false)
+	runCmd.PersistentFlags().StringVarP(&runFileName, "filename", "f", "gofile.yml", "Path to gofile")
+	runCmd.PersistentFlags().BoolVar(&runLock, "lock", false, "Record and verify module hashes in gofile.lock next to the gofile")
+	runCmd.PersistentFlags().StringVar(&runLockFile, "lockfile", "", "Path to the lockfile of module hashes, implies --lock")
+	runCmd.PersistentFlags().BoolVar(&runForce, "force", false, "Rebuild the binary even when already built")
+
+	// The default is empty when the cache directory is unknown, which is
+	// reported when building.
+	defaultToolsDir, _ := pkg.DefaultToolsDir()
+	runCmd.PersistentFlags().StringVar(&toolsDir, "tools-dir", defaultToolsDir, "Directory caching the binaries built to run")
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/retr0h/gofile/utils"
+)
+
+// CheckResult containing the state of a package's installed binary.
+type CheckResult struct {
+	Name      string `json:"name"`
+	Binary    string `json:"binary"`
+	Installed bool   `json:"installed"`
+	GoVersion string `json:"go_version,omitempty"` // GoVersion which built the binary, empty when not a go binary.
+	Go        string `json:"go,omitempty"`         // Go toolchain the gofile requests.
+	SHA256    string `json:"sha256,omitempty"`     // SHA256 of the binary, when verified.
+	Modified  bool   `json:"modified,omitempty"`   // Modified since installed, when verified.
+}
+
+// Check inspects the binary of each package, reporting whether it is
+// installed and which go release built it.  When verifying, binaries are
+// hashed and compared against the hash recorded when installed, or the
+// package's checksum of a built binary.
+func (p *Packages) Check(verify bool) ([]*CheckResult, error) {
+	steps, err := p.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &State{}
+	if verify && p.StateFile != "" {
+		if state, err = LoadState(p.StateFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []*CheckResult
+	for _, step := range steps {
+		result := &CheckResult{
+			Name:   step.Name(),
+			Binary: step.Binary,
+			Go:     step.Go,
+		}
+
+		if _, err := os.Stat(step.Binary); os.IsNotExist(err) {
+			results = append(results, result)
+			continue
+		}
+
+		// Prebuilt binaries of releases may not be go binaries, which have no
+		// build info.
+		result.Installed = true
+		if info, err := readBuildInfo(step.Binary); err == nil {
+			result.GoVersion = info.GoVersion
+		}
+
+		if verify {
+			if result.SHA256, err = hashFile(step.Binary); err != nil {
+				return nil, err
+			}
+			result.Modified = isModified(step, state, result.SHA256)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// isModified returns true when the provided hash of the step's binary does
+// not match the hash recorded when installed, or the step's checksum of a
+// built binary.
+func isModified(step *Step, state *State, sum string) bool {
+	if installed, ok := state.Installed[step.Binary]; ok && verifyChecksum(step.Binary, installed.SHA256, sum) != nil {
+		return true
+	}
+
+	// The checksum of a release is of the downloaded asset, not the binary.
+	if step.Release == nil && step.SHA256 != "" && verifyChecksum(step.Binary, step.SHA256, sum) != nil {
+		return true
+	}
+
+	return false
+}
+
+// PrintCheck writes the provided check results to w in the requested format.
+func PrintCheck(w io.Writer, results []*CheckResult, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(results)
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, result := range results {
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case FormatText:
+		for _, result := range results {
+			if !result.Installed {
+				fmt.Fprintf(w, "%s: %s (%s)\n", utils.Color.Cyan(result.Name), utils.Color.Red("not installed"), result.Binary)
+				continue
+			}
+
+			if result.GoVersion != "" {
+				fmt.Fprintf(w, "%s: built with %s (%s)", utils.Color.Cyan(result.Name), result.GoVersion, result.Binary)
+			} else {
+				fmt.Fprintf(w, "%s: installed (%s)", utils.Color.Cyan(result.Name), result.Binary)
+			}
+			if result.Go != "" {
+				fmt.Fprintf(w, ", gofile requests %s", result.Go)
+			}
+			if result.Modified {
+				fmt.Fprintf(w, " %s", utils.Color.Red("modified"))
+			} else if result.SHA256 != "" {
+				fmt.Fprintf(w, " %s", utils.Color.Green("verified"))
+			}
+			fmt.Fprintln(w)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown format '%s'", format)
+	}
+}
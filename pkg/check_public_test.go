@@ -0,0 +1,151 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	defer setenv("GO111MODULE", "on")()
+	goBin, _ := exec.LookPath("go")
+	os.Symlink(goBin, filepath.Join(dir, "go"))
+	data := `
+---
+- url: golang.org/x/tools/cmd/go
+- url: github.com/simeji/jid/cmd/jid
+`
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Check(false)
+	want := []*pkg.CheckResult{
+		{
+			Name:      "golang.org/x/tools/cmd/go",
+			Binary:    filepath.Join(dir, "go"),
+			Installed: true,
+			GoVersion: runtime.Version(),
+		},
+		{
+			Name:   "github.com/simeji/jid/cmd/jid",
+			Binary: filepath.Join(dir, "jid"),
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestCheckReportsBinaryWithoutBuildInfo(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	ioutil.WriteFile(filepath.Join(dir, "jid"), []byte("#!/bin/sh\n"), 0755)
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+- url: golang.org/x/lint/golint
+  mode: gopath
+`
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Check(false)
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.True(t, got[0].Installed)
+	assert.Empty(t, got[0].GoVersion)
+	assert.False(t, got[1].Installed)
+
+	var buf bytes.Buffer
+	pkg.PrintCheck(&buf, got[:1], pkg.FormatText)
+
+	assert.Equal(t, fmt.Sprintf("github.com/simeji/jid/cmd/jid: installed (%s)\n", filepath.Join(dir, "jid")), buf.String())
+}
+
+func TestCheckVerify(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	stateFile := filepath.Join(dir, "state.json")
+	goBin, _ := exec.LookPath("go")
+	src, _ := ioutil.ReadFile(goBin)
+	ioutil.WriteFile(filepath.Join(dir, "go"), src, 0755)
+	ioutil.WriteFile(filepath.Join(dir, "gofmt"), src, 0755)
+	sum := fmt.Sprintf("%x", sha256.Sum256(src))
+	state := &pkg.State{Installed: map[string]*pkg.Installed{
+		filepath.Join(dir, "go"):    {SHA256: sum},
+		filepath.Join(dir, "gofmt"): {SHA256: "0000"},
+	}}
+	state.Save(stateFile)
+	data := `
+---
+- url: golang.org/x/tools/cmd/go
+  mode: gopath
+- url: golang.org/x/tools/cmd/gofmt
+  mode: gopath
+`
+	p := pkg.Packages{
+		StateFile: stateFile,
+	}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Check(true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, sum, got[0].SHA256)
+	assert.False(t, got[0].Modified)
+	assert.True(t, got[1].Modified)
+}
+
+func TestPrintCheckNDJSON(t *testing.T) {
+	results := []*pkg.CheckResult{
+		{Name: "github.com/simeji/jid/cmd/jid", Binary: "/gobin/jid", Installed: true, GoVersion: "go1.20.3"},
+		{Name: "golang.org/x/lint/golint", Binary: "/gobin/golint"},
+	}
+	var buf bytes.Buffer
+	err := pkg.PrintCheck(&buf, results, pkg.FormatNDJSON)
+	want := `{"name":"github.com/simeji/jid/cmd/jid","binary":"/gobin/jid","installed":true,"go_version":"go1.20.3"}
+{"name":"golang.org/x/lint/golint","binary":"/gobin/golint","installed":false}
+`
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, buf.String())
+}
+
+func TestPrintCheckReturnsErrorWithUnknownFormat(t *testing.T) {
+	err := pkg.PrintCheck(&bytes.Buffer{}, nil, "yaml")
+
+	assert.Equal(t, "unknown format 'yaml'", err.Error())
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/retr0h/gofile/utils"
+)
+
+// Shells which completion scripts are generated for.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// DefaultCompletionDir returns the directory completion scripts are
+// generated into, `$XDG_DATA_HOME/gofile/completions` defaulting to
+// `~/.local/share/gofile/completions`.
+func DefaultCompletionDir() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(dir, "gofile", "completions"), nil
+}
+
+// completions runs the step's binary with its completion arguments for each
+// shell, writing the completion scripts into `Packages.CompletionDir`, when
+// both are set.
+func (p *Packages) completions(ctx context.Context, step *Step) error {
+	if step.Completion == "" || p.CompletionDir == "" {
+		return nil
+	}
+
+	shells := p.CompletionShells
+	if len(shells) == 0 {
+		shells = Shells
+	}
+
+	for _, shell := range shells {
+		file, err := completionFile(p.CompletionDir, shell, step.Binary)
+		if err != nil {
+			return err
+		}
+		p.log().Infof("Generating %s completion: %s\n", shell, utils.Color.Cyan(file))
+
+		if err := p.completion(ctx, step, shell, file); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return fmt.Errorf("generating %s completion failed: %w", shell, err)
+		}
+	}
+
+	return nil
+}
+
+// completion writes the completion script of the step's binary for the
+// provided shell to file, removing the file when the binary fails.
+func (p *Packages) completion(ctx context.Context, step *Step, shell string, file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	err = p.runCommand(ctx, &Command{
+		Name:   step.Binary,
+		Args:   completionArgs(step.Completion, shell),
+		Stdout: f,
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file)
+	}
+
+	return err
+}
+
+// completionArgs returns the arguments generating the completion script of
+// the provided shell, which replaces `{shell}` in completion, or is appended
+// when completion does not contain it.
+func completionArgs(completion string, shell string) []string {
+	if !strings.Contains(completion, "{shell}") {
+		completion += " {shell}"
+	}
+
+	return strings.Fields(strings.Replace(completion, "{shell}", shell, -1))
+}
+
+// completionFile returns the path of the completion script of the provided
+// binary within dir, named as each shell loads it.
+func completionFile(dir string, shell string, binary string) (string, error) {
+	name := strings.TrimSuffix(filepath.Base(binary), ".exe")
+
+	switch shell {
+	case "bash":
+		return filepath.Join(dir, shell, name), nil
+	case "zsh":
+		return filepath.Join(dir, shell, "_"+name), nil
+	case "fish":
+		return filepath.Join(dir, shell, name+".fish"), nil
+	default:
+		return "", fmt.Errorf("unsupported shell '%s'", shell)
+	}
+}
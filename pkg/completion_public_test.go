@@ -0,0 +1,122 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/retr0h/gofile/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// completionRunner writes each command capturing stdout as its output.
+type completionRunner struct {
+	fakeRunner
+}
+
+func (r *completionRunner) Run(ctx context.Context, cmd *pkg.Command) error {
+	if cmd.Stdout != nil {
+		io.WriteString(cmd.Stdout, cmd.String())
+	}
+
+	return r.fakeRunner.Run(ctx, cmd)
+}
+
+func TestInstallGeneratesCompletions(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", "/gobin")()
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  completion: completion --shell {shell}
+`
+	r := &completionRunner{}
+	p := pkg.Packages{
+		Logger:           &utils.Logger{Level: utils.LevelError},
+		Runner:           r,
+		CompletionDir:    dir,
+		CompletionShells: []string{"bash", "zsh"},
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, r.commands, 3)
+
+	got, _ := ioutil.ReadFile(filepath.Join(dir, "bash", "jid"))
+	assert.Equal(t, "/gobin/jid completion --shell bash", string(got))
+
+	got, _ = ioutil.ReadFile(filepath.Join(dir, "zsh", "_jid"))
+	assert.Equal(t, "/gobin/jid completion --shell zsh", string(got))
+}
+
+func TestInstallDoesNotGenerateCompletionsWithoutDir(t *testing.T) {
+	defer setenv("GOBIN", "/gobin")()
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  completion: completion
+`
+	r := &completionRunner{}
+	p := pkg.Packages{
+		Logger: &utils.Logger{Level: utils.LevelError},
+		Runner: r,
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, r.commands, 1)
+}
+
+func TestInstallRemovesCompletionWhenBinaryFails(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", "/gobin")()
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  completion: completion
+`
+	r := &completionRunner{fakeRunner{errs: []error{nil, errors.New("exit status 1")}}}
+	p := pkg.Packages{
+		Logger:           &utils.Logger{Level: utils.LevelError},
+		Runner:           r,
+		CompletionDir:    dir,
+		CompletionShells: []string{"bash"},
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+
+	assert.EqualError(t, err, "installing 'github.com/simeji/jid/cmd/jid' failed: generating bash completion failed: exit status 1")
+	assert.False(t, fileExists(filepath.Join(dir, "bash", "jid")))
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletionArgsAppendsShell(t *testing.T) {
+	got := completionArgs("completion", "bash")
+
+	assert.Equal(t, []string{"completion", "bash"}, got)
+}
+
+func TestCompletionArgsReplacesShell(t *testing.T) {
+	got := completionArgs("completion -s {shell} --no-descriptions", "zsh")
+
+	assert.Equal(t, []string{"completion", "-s", "zsh", "--no-descriptions"}, got)
+}
+
+func TestCompletionFile(t *testing.T) {
+	tests := map[string]string{
+		"bash": "/completions/bash/jid",
+		"zsh":  "/completions/zsh/_jid",
+		"fish": "/completions/fish/jid.fish",
+	}
+
+	for shell, want := range tests {
+		got, err := completionFile("/completions", shell, "/gobin/jid")
+
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestCompletionFileReturnsErrorWithUnsupportedShell(t *testing.T) {
+	_, err := completionFile("/completions", "tcsh", "/gobin/jid")
+
+	assert.EqualError(t, err, "unsupported shell 'tcsh'")
+}
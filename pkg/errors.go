@@ -0,0 +1,97 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ManifestError is returned when a gofile cannot be read or parsed, and
+// wraps any `ValidationError`.
+type ManifestError struct {
+	File string // File of the gofile.
+	Err  error  // Err reading, parsing, or validating the gofile.
+}
+
+// Error returns the error reading, parsing, or validating the gofile.
+func (e *ManifestError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error reading, parsing, or validating the gofile.
+func (e *ManifestError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned when a gofile does not match the schema, or
+// sets options which cannot be combined.
+type ValidationError struct {
+	Errors []string // Errors found in the gofile.
+}
+
+// Error returns the errors found in the gofile, one per line.
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Errors, "\n")
+}
+
+// InstallError is returned when a package fails to install.
+type InstallError struct {
+	Package  string // Package which failed, its URL, path, or download URL.
+	Attempts int    // Attempts made to install the package.
+	Err      error  // Err of the last attempt.
+}
+
+// Error returns the package, and the error of the last attempt.
+func (e *InstallError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("installing '%s' failed after %d attempts: %s", e.Package, e.Attempts, e.Err)
+	}
+
+	return fmt.Sprintf("installing '%s' failed: %s", e.Package, e.Err)
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *InstallError) Unwrap() error {
+	return e.Err
+}
+
+// Transient returns true when the package failed with a network error, which
+// is likely to go away on its own.
+func (e *InstallError) Transient() bool {
+	return isTransient(e.Err)
+}
+
+// ToolchainNotFoundError is returned when the go toolchain requested by a
+// gofile or package is not installed.
+type ToolchainNotFoundError struct {
+	Toolchain string // Toolchain requested, a path or a version.
+	Searched  string // Searched describes where the toolchain was searched for, when not a path.
+}
+
+// Error returns the toolchain, and where it was searched for.
+func (e *ToolchainNotFoundError) Error() string {
+	if e.Searched == "" {
+		return fmt.Sprintf("toolchain '%s' not found", e.Toolchain)
+	}
+
+	return fmt.Sprintf("toolchain '%s' not found in %s", e.Toolchain, e.Searched)
+}
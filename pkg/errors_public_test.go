@@ -0,0 +1,108 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalYAMLFileReturnsManifestError(t *testing.T) {
+	p := pkg.Packages{}
+	err := p.UnmarshalYAMLFile("missing.yml")
+
+	var manifestErr *pkg.ManifestError
+	assert.True(t, errors.As(err, &manifestErr))
+	assert.Equal(t, "missing.yml", manifestErr.File)
+	assert.True(t, os.IsNotExist(manifestErr.Err))
+}
+
+func TestUnmarshalYAMLFileReturnsValidationError(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "gofile.yml")
+	ioutil.WriteFile(filename, []byte("foo: bar\n"), 0644)
+	p := pkg.Packages{}
+	err := p.UnmarshalYAMLFile(filename)
+
+	var manifestErr *pkg.ManifestError
+	var validationErr *pkg.ValidationError
+	assert.True(t, errors.As(err, &manifestErr))
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, []string{
+		"packages: packages is required",
+		"foo: Additional property foo is not allowed",
+	}, validationErr.Errors)
+}
+
+func TestPlanReturnsToolchainNotFoundError(t *testing.T) {
+	p := pkg.Packages{
+		Packages: []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Go: "/missing/go"}},
+	}
+	_, err := p.Plan()
+
+	var toolchainErr *pkg.ToolchainNotFoundError
+	assert.True(t, errors.As(err, &toolchainErr))
+	assert.Equal(t, "/missing/go", toolchainErr.Toolchain)
+}
+
+func TestPlanReturnsValidationErrorWithVersionInGOPATHMode(t *testing.T) {
+	p := pkg.Packages{
+		Packages: []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Version: "v0.7.6", Mode: pkg.ModeGOPATH}},
+	}
+	_, err := p.Plan()
+
+	var validationErr *pkg.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+}
+
+func TestInstallReturnsInstallError(t *testing.T) {
+	defer setenv("GOBIN", "/gobin")()
+	cmdErr := &pkg.CommandError{Err: errors.New("exit status 1"), Stderr: "cannot find package"}
+	p := pkg.Packages{
+		Packages: []pkg.Package{{URL: "invalid.", Mode: pkg.ModeGOPATH}},
+		Runner:   &fakeRunner{err: cmdErr},
+	}
+	err := p.Install(context.Background())
+
+	var installErr *pkg.InstallError
+	assert.True(t, errors.As(err, &installErr))
+	assert.Equal(t, "invalid.", installErr.Package)
+	assert.Equal(t, 1, installErr.Attempts)
+	assert.Equal(t, cmdErr, installErr.Err)
+	assert.False(t, installErr.Transient())
+}
+
+func TestInstallErrorTransient(t *testing.T) {
+	err := &pkg.InstallError{
+		Package: "github.com/simeji/jid/cmd/jid",
+		Err:     &pkg.CommandError{Err: errors.New("exit status 1"), Stderr: "reading https://proxy.golang.org/foo: 502 Bad Gateway"},
+	}
+
+	assert.True(t, err.Transient())
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/retr0h/gofile/utils"
+)
+
+// Types of the events emitted for each package while installing.  Output
+// events are only passed to observers.
+const (
+	EventStart    = "start"
+	EventSuccess  = "success"
+	EventFailure  = "failure"
+	EventUpToDate = "up_to_date"
+	EventOutput   = "output"
+)
+
+// Event describing the progress of installing a package, passed to each
+// `Observer`, and written as JSON by an `EventWriter`.
+type Event struct {
+	Type     string    `json:"type"`
+	Package  string    `json:"package"`
+	Binary   string    `json:"binary,omitempty"`
+	Time     time.Time `json:"time"`
+	Duration float64   `json:"duration,omitempty"` // Duration of the install in seconds.
+	Error    string    `json:"error,omitempty"`
+	Stderr   string    `json:"stderr,omitempty"` // Stderr of the failed command.
+	Output   string    `json:"-"`                // Output of the running command.
+	Err      error     `json:"-"`                // Err which failed the install.
+	step     *Step     // Step of the package.
+}
+
+// log returns the logger of installs.
+func (p *Packages) log() *utils.Logger {
+	if p.Logger != nil {
+		return p.Logger
+	}
+
+	return &utils.Logger{Level: utils.LevelInfo}
+}
+
+// EventWriter observes installs, writing each event as a line of JSON, or
+// collecting them to write as a JSON array once installed.  The output of
+// commands is logged rather than written as events.
+type EventWriter struct {
+	w      io.Writer
+	format string
+	events []*Event
+	err    error // Err writing an event, events are no longer written once set.
+}
+
+// NewEventWriter returns an observer writing events to w in the provided
+// format, which writes nothing unless the format is a JSON format.
+func NewEventWriter(w io.Writer, format string) *EventWriter {
+	return &EventWriter{w: w, format: format}
+}
+
+// OnStart writes the event of a package starting to install.
+func (ew *EventWriter) OnStart(e *Event) { ew.write(e) }
+
+// OnOutput ignores the output of commands.
+func (ew *EventWriter) OnOutput(e *Event) {}
+
+// OnSuccess writes the event of a package installed.
+func (ew *EventWriter) OnSuccess(e *Event) { ew.write(e) }
+
+// OnFailure writes the event of a package failing to install.
+func (ew *EventWriter) OnFailure(e *Event) { ew.write(e) }
+
+// OnSkip writes the event of a package up to date.
+func (ew *EventWriter) OnSkip(e *Event) { ew.write(e) }
+
+// OnFinish writes the collected events as a JSON array, returning the first
+// error writing events.
+func (ew *EventWriter) OnFinish(results []*Result, err error) error {
+	if ew.err != nil || ew.format != FormatJSON {
+		return ew.err
+	}
+
+	events := ew.events
+	if events == nil {
+		events = []*Event{}
+	}
+	ew.events = nil
+
+	enc := json.NewEncoder(ew.w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(events)
+}
+
+// write writes the provided event as a line of JSON, or collects it.
+func (ew *EventWriter) write(e *Event) {
+	if ew.err != nil {
+		return
+	}
+
+	switch ew.format {
+	case FormatNDJSON:
+		ew.err = json.NewEncoder(ew.w).Encode(e)
+	case FormatJSON:
+		ew.events = append(ew.events, e)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstallEmitsNDJSON(t *testing.T) {
+	defer setenv("GOBIN", "/gobin")()
+	var buf bytes.Buffer
+	p := pkg.Packages{
+		Packages:  []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Mode: pkg.ModeGOPATH}},
+		Runner:    &fakeRunner{},
+		Observers: []pkg.Observer{pkg.NewEventWriter(&buf, pkg.FormatNDJSON)},
+	}
+	err := p.Install(context.Background())
+	got := decodeEvents(t, &buf)
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, pkg.EventStart, got[0].Type)
+	assert.Equal(t, "github.com/simeji/jid/cmd/jid", got[0].Package)
+	assert.Equal(t, "/gobin/jid", got[0].Binary)
+	assert.Equal(t, pkg.EventSuccess, got[1].Type)
+}
+
+func TestInstallEmitsNDJSONFailureWithStderr(t *testing.T) {
+	defer setenv("GOBIN", "/gobin")()
+	var buf bytes.Buffer
+	p := pkg.Packages{
+		Packages:  []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Mode: pkg.ModeGOPATH}},
+		Runner:    &fakeRunner{err: &pkg.CommandError{Err: errors.New("exit status 1"), Stderr: "cannot find package"}},
+		Observers: []pkg.Observer{pkg.NewEventWriter(&buf, pkg.FormatNDJSON)},
+	}
+	err := p.Install(context.Background())
+	got := decodeEvents(t, &buf)
+
+	assert.Error(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, pkg.EventFailure, got[1].Type)
+	assert.Equal(t, "installing 'github.com/simeji/jid/cmd/jid' failed: exit status 1: cannot find package", got[1].Error)
+	assert.Equal(t, "cannot find package", got[1].Stderr)
+}
+
+func TestInstallEmitsJSON(t *testing.T) {
+	defer setenv("GOBIN", "/gobin")()
+	var buf bytes.Buffer
+	p := pkg.Packages{
+		Packages: []pkg.Package{
+			{URL: "github.com/simeji/jid/cmd/jid", Mode: pkg.ModeGOPATH},
+			{URL: "golang.org/x/lint/golint", Mode: pkg.ModeGOPATH},
+		},
+		Runner:    &fakeRunner{},
+		Observers: []pkg.Observer{pkg.NewEventWriter(&buf, pkg.FormatJSON)},
+	}
+	err := p.Install(context.Background())
+	var got []*pkg.Event
+	json.Unmarshal(buf.Bytes(), &got)
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 4)
+	assert.Equal(t, pkg.EventSuccess, got[3].Type)
+	assert.Equal(t, "golang.org/x/lint/golint", got[3].Package)
+}
+
+func TestInstallEmitsJSONWhenPlanFails(t *testing.T) {
+	var buf bytes.Buffer
+	p := pkg.Packages{
+		Packages:  []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Go: "/missing/go"}},
+		Observers: []pkg.Observer{pkg.NewEventWriter(&buf, pkg.FormatJSON)},
+	}
+	err := p.Install(context.Background())
+
+	assert.Error(t, err)
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func decodeEvents(t *testing.T, buf *bytes.Buffer) []*pkg.Event {
+	var events []*pkg.Event
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		e := &pkg.Event{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), e))
+		events = append(events, e)
+	}
+
+	return events
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/retr0h/gofile/utils"
+)
+
+// runHooks runs the provided hooks of the step in order, stopping at the
+// first hook which fails.  Unlike the output of go commands, the output of
+// hooks is logged at the info level.
+func (p *Packages) runHooks(ctx context.Context, step *Step, kind string, hooks []string) error {
+	for _, hook := range hooks {
+		p.log().Infof("Running %s hook: %s\n", kind, utils.Color.Cyan(hook))
+
+		if err := p.runCommandAt(ctx, hookCommand(step, hook, p.dir), utils.LevelInfo); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return fmt.Errorf("%s hook '%s' failed: %w", kind, hook, err)
+		}
+	}
+
+	return nil
+}
+
+// hookCommand returns the command running the provided hook with the shell,
+// from dir, and with the variables of the step's package in its environment.
+func hookCommand(step *Step, hook string, dir string) *Command {
+	cmd := &Command{
+		Name: "sh",
+		Args: []string{"-c", hook},
+		Env: []string{
+			"GOFILE_PACKAGE=" + step.Name(),
+			"GOFILE_URL=" + step.URL,
+			"GOFILE_VERSION=" + step.Version,
+			"GOFILE_BINARY=" + step.Binary,
+			"GOFILE_BIN_DIR=" + step.BinDir,
+		},
+		Dir: dir,
+	}
+	if runtime.GOOS == "windows" {
+		cmd.Name = "cmd"
+		cmd.Args = []string{"/C", hook}
+	}
+
+	return cmd
+}
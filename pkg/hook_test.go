@@ -0,0 +1,71 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/retr0h/gofile/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHookCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks run with cmd on windows")
+	}
+	step := &Step{
+		URL:     "github.com/simeji/jid/cmd/jid",
+		Version: "v0.7.6",
+		Binary:  "/gobin/jid",
+		BinDir:  "/gobin",
+	}
+	got := hookCommand(step, "jid --version", "/gofile")
+	want := &Command{
+		Name: "sh",
+		Args: []string{"-c", "jid --version"},
+		Env: []string{
+			"GOFILE_PACKAGE=github.com/simeji/jid/cmd/jid",
+			"GOFILE_URL=github.com/simeji/jid/cmd/jid",
+			"GOFILE_VERSION=v0.7.6",
+			"GOFILE_BINARY=/gobin/jid",
+			"GOFILE_BIN_DIR=/gobin",
+		},
+		Dir: "/gofile",
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func TestRunHooksLogsOutputAtInfo(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("runs sh")
+	}
+	var out bytes.Buffer
+	p := Packages{Logger: &utils.Logger{Level: utils.LevelInfo, Out: &out}}
+	step := &Step{URL: "github.com/simeji/jid/cmd/jid", Binary: "/gobin/jid"}
+	err := p.runHooks(context.Background(), step, "post-install", []string{`echo "installed $GOFILE_BINARY"`})
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "installed /gobin/jid\n")
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// planStaged completes the provided step with the throwaway GOPATH it
+// installs into, so its binary is verified before reaching the bin directory.
+// Isolated steps also build within the throwaway GOPATH, module cache, and
+// build cache, the caches are kept in `Packages.CacheDir` when set.
+func (p *Packages) planStaged(ctx context.Context, step *Step) error {
+	if step.TempDir == "" {
+		dir, err := tempDirPath()
+		if err != nil {
+			return err
+		}
+		step.TempDir = dir
+	}
+	step.Stage = filepath.Join(step.TempDir, "gopath")
+
+	step.Command = withEnv(step.Command, "GOBIN="+filepath.Join(step.Stage, "bin"))
+	if step.Isolated {
+		cmd, err := p.isolate(ctx, step.Command, step.Stage)
+		if err != nil {
+			return err
+		}
+		step.Command = cmd
+	}
+
+	return nil
+}
+
+// installStaged installs the provided step into its stage, verifies the
+// resulting binary against the step's checksum and the lock, and copies it
+// into the step's bin directory.
+func (p *Packages) installStaged(ctx context.Context, step *Step) error {
+	if err := p.installStep(ctx, step); err != nil {
+		return err
+	}
+
+	src := filepath.Join(step.Stage, "bin", filepath.Base(step.Binary))
+	if p.locks(step) {
+		if err := p.verifyModule(src); err != nil {
+			return err
+		}
+	}
+
+	if step.SHA256 != "" {
+		if err := verifyFile(src, step.SHA256); err != nil {
+			return err
+		}
+	}
+
+	return copyBinary(src, step.Binary)
+}
+
+// isolate returns a copy of the provided command, which builds within the
+// provided GOPATH.  The module cache is only moved, and made writable, by
+// toolchains supporting GOMODCACHE (go1.15) and -modcacherw (go1.14).
+func (p *Packages) isolate(ctx context.Context, cmd *Command, gopath string) (*Command, error) {
+	cacheDir := p.CacheDir
+	if cacheDir == "" {
+		cacheDir = filepath.Join(gopath, "cache")
+	}
+
+	// GOCACHE must be absolute.
+	cacheDir, err := filepath.Abs(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	major, minor, err := p.goVersion(ctx, cmd.Name)
+	if err != nil {
+		return nil, err
+	}
+	supports := func(m int) bool { return major > 1 || minor >= m }
+
+	env := []string{
+		"GOPATH=" + gopath,
+		"GOBIN=" + filepath.Join(gopath, "bin"),
+	}
+	if supports(15) {
+		env = append(env, "GOMODCACHE="+filepath.Join(cacheDir, "mod"))
+	}
+	env = append(env, "GOCACHE="+filepath.Join(cacheDir, "build"))
+	if supports(14) {
+		// The module cache is read-only by default, which prevents removing
+		// the GOPATH afterwards.
+		env = append(env, "GOFLAGS="+strings.TrimSpace(os.Getenv("GOFLAGS")+" -modcacherw"))
+	}
+
+	return withEnv(cmd, env...), nil
+}
+
+// withEnv returns a copy of the provided command, with the provided `KEY=value`
+// pairs replacing any of the command's environment with the same keys.
+func withEnv(cmd *Command, env ...string) *Command {
+	replaced := make(map[string]bool)
+	for _, e := range env {
+		replaced[strings.SplitN(e, "=", 2)[0]] = true
+	}
+
+	var merged []string
+	for _, e := range cmd.Env {
+		if !replaced[strings.SplitN(e, "=", 2)[0]] {
+			merged = append(merged, e)
+		}
+	}
+
+	c := *cmd
+	c.Env = append(merged, env...)
+
+	return &c
+}
+
+// copyBinary copies the binary at src to dst, replacing dst atomically.
+func copyBinary(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("build did not produce '%s'", filepath.Base(src))
+	}
+	defer in.Close()
+
+	return writeBinary(in, dst)
+}
+
+// writeBinary writes an executable to dst from the provided reader, replacing
+// dst atomically.
+func writeBinary(r io.Reader, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	out, err := ioutil.TempFile(filepath.Dir(dst), "."+filepath.Base(dst))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(out.Name(), 0755); err != nil {
+		return err
+	}
+
+	return os.Rename(out.Name(), dst)
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsolate(t *testing.T) {
+	t.Setenv("GOFLAGS", "-mod=mod")
+	p := Packages{Runner: &versionRunner{out: "go version go1.21.0 linux/amd64"}}
+	cmd := &Command{
+		Name: "go",
+		Args: []string{"install", "github.com/simeji/jid/cmd/jid@latest"},
+		Env:  []string{"GOBIN=/gobin", "GO111MODULE=on"},
+	}
+	got, err := p.isolate(context.Background(), cmd, "/tmp/gopath")
+	want := []string{
+		"GO111MODULE=on",
+		"GOPATH=/tmp/gopath",
+		"GOBIN=/tmp/gopath/bin",
+		"GOMODCACHE=/tmp/gopath/cache/mod",
+		"GOCACHE=/tmp/gopath/cache/build",
+		"GOFLAGS=-mod=mod -modcacherw",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got.Env)
+	assert.Equal(t, cmd.Args, got.Args)
+	assert.Equal(t, []string{"GOBIN=/gobin", "GO111MODULE=on"}, cmd.Env)
+}
+
+func TestIsolateWithCacheDir(t *testing.T) {
+	t.Setenv("GOFLAGS", "")
+	p := Packages{CacheDir: "/cache", Runner: &versionRunner{out: "go version go1.21.0 linux/amd64"}}
+	got, err := p.isolate(context.Background(), &Command{Name: "go"}, "/tmp/gopath")
+	want := []string{
+		"GOPATH=/tmp/gopath",
+		"GOBIN=/tmp/gopath/bin",
+		"GOMODCACHE=/cache/mod",
+		"GOCACHE=/cache/build",
+		"GOFLAGS=-modcacherw",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got.Env)
+}
+
+func TestIsolateWithRelativeCacheDir(t *testing.T) {
+	t.Setenv("GOFLAGS", "")
+	wd, _ := os.Getwd()
+	p := Packages{CacheDir: "st/cache", Runner: &versionRunner{out: "go version go1.21.0 linux/amd64"}}
+	got, err := p.isolate(context.Background(), &Command{Name: "go"}, "/tmp/gopath")
+
+	assert.NoError(t, err)
+	assert.Contains(t, got.Env, "GOMODCACHE="+filepath.Join(wd, "st", "cache", "mod"))
+	assert.Contains(t, got.Env, "GOCACHE="+filepath.Join(wd, "st", "cache", "build"))
+}
+
+func TestIsolateWithOldToolchain(t *testing.T) {
+	t.Setenv("GOFLAGS", "")
+	tests := map[string][]string{
+		"go version go1.13.15 linux/amd64": {
+			"GOPATH=/tmp/gopath",
+			"GOBIN=/tmp/gopath/bin",
+			"GOCACHE=/cache/build",
+		},
+		"go version go1.14.15 linux/amd64": {
+			"GOPATH=/tmp/gopath",
+			"GOBIN=/tmp/gopath/bin",
+			"GOCACHE=/cache/build",
+			"GOFLAGS=-modcacherw",
+		},
+	}
+	for out, want := range tests {
+		p := Packages{CacheDir: "/cache", Runner: &versionRunner{out: out}}
+		got, err := p.isolate(context.Background(), &Command{Name: "go"}, "/tmp/gopath")
+
+		assert.NoError(t, err)
+		assert.Equal(t, want, got.Env, out)
+	}
+}
+
+func TestWithEnv(t *testing.T) {
+	cmd := &Command{Name: "go", Env: []string{"GOBIN=/gobin", "GO111MODULE=on"}}
+	got := withEnv(cmd, "GOBIN=/tmp/bin", "GOPATH=/tmp")
+
+	assert.Equal(t, []string{"GO111MODULE=on", "GOBIN=/tmp/bin", "GOPATH=/tmp"}, got.Env)
+	assert.Equal(t, []string{"GOBIN=/gobin", "GO111MODULE=on"}, cmd.Env)
+}
+
+func TestCopyBinary(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src", "jid")
+	dst := filepath.Join(dir, "bin", "jid")
+	os.MkdirAll(filepath.Dir(src), 0755)
+	ioutil.WriteFile(src, []byte("binary"), 0644)
+
+	err := copyBinary(src, dst)
+	got, _ := ioutil.ReadFile(dst)
+	fi, _ := os.Stat(dst)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "binary", string(got))
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+}
+
+func TestCopyBinaryReturnsErrorWhenMissing(t *testing.T) {
+	err := copyBinary("/missing/jid", "/gobin/jid")
+	want := "build did not produce 'jid'"
+
+	assert.Equal(t, want, err.Error())
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/retr0h/gofile/utils"
+)
+
+// planLocalPackage completes the provided step with the command installing
+// the package from its local checkout.  Any replacements are applied through
+// a copy of the checkout's go.mod, leaving the checkout untouched.
+func (p *Packages) planLocalPackage(step *Step, pkg Package) (*Step, error) {
+	path, err := p.absPath(pkg.Path)
+	if err != nil {
+		return nil, err
+	}
+	step.Path = path
+	if step.importPath, err = localImportPath(path); err != nil {
+		return nil, err
+	}
+
+	goCmdArgs := []string{"install"}
+	if p.log().Level >= utils.LevelTrace {
+		goCmdArgs = append(goCmdArgs, "-v")
+	}
+
+	if len(pkg.Replace) > 0 {
+		step.Replace = make(map[string]string, len(pkg.Replace))
+		for module, replacement := range pkg.Replace {
+			if step.Replace[module], err = p.absPath(replacement); err != nil {
+				return nil, err
+			}
+		}
+
+		if step.TempDir, err = tempDirPath(); err != nil {
+			return nil, err
+		}
+		step.Modfile = filepath.Join(step.TempDir, "go.mod")
+		goCmdArgs = append(goCmdArgs, "-modfile="+step.Modfile)
+	}
+	goCmdArgs = append(goCmdArgs, ".")
+
+	step.Command = &Command{
+		Name: "go",
+		Args: goCmdArgs,
+		Env:  []string{"GOBIN=" + step.BinDir},
+		Dir:  path,
+	}
+
+	return step, nil
+}
+
+// absPath returns the provided path made absolute relative to the directory
+// of the gofile, or the current directory when not read from a file.
+func (p *Packages) absPath(path string) (string, error) {
+	if !filepath.IsAbs(path) && p.dir != "" {
+		path = filepath.Join(p.dir, path)
+	}
+
+	return filepath.Abs(path)
+}
+
+// writeModfile writes the checkout's go.mod with the step's replacements
+// appended, along with a copy of the checkout's go.sum.
+func writeModfile(step *Step) error {
+	root, err := moduleRoot(step.Path)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return err
+	}
+
+	data = append(data, '\n')
+	for _, module := range sortedKeys(step.Replace) {
+		data = append(data, fmt.Sprintf("replace %s => %q\n", module, step.Replace[module])...)
+	}
+
+	if err := ioutil.WriteFile(step.Modfile, data, 0644); err != nil {
+		return err
+	}
+
+	sum, err := ioutil.ReadFile(filepath.Join(root, "go.sum"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(filepath.Dir(step.Modfile), "go.sum"), sum, 0644)
+}
+
+// moduleRoot returns the closest directory containing a go.mod, starting at
+// the provided path and walking up the tree.
+func moduleRoot(path string) (string, error) {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+
+		if dir == filepath.Dir(dir) {
+			return "", fmt.Errorf("no go.mod found in '%s' or any parent directory", path)
+		}
+	}
+}
+
+// localImportPath returns the import path of the checkout at the provided
+// path, from the module path in its go.mod, which `go install` names the
+// binary after rather than the checkout's directory.  Checkouts outside a
+// module are named after their directory.
+func localImportPath(path string) (string, error) {
+	root, err := moduleRoot(path)
+	if err != nil {
+		return filepath.Base(path), nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+
+	module := modulePath(data)
+	if module == "" {
+		return "", fmt.Errorf("no module path found in '%s'", filepath.Join(root, "go.mod"))
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return module, nil
+	}
+
+	return module + "/" + filepath.ToSlash(rel), nil
+}
+
+// modulePath returns the module path declared by the provided go.mod, or an
+// empty string when it declares none.
+func modulePath(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+
+	return ""
+}
+
+// sortedKeys returns the keys of the provided map in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
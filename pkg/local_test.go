@@ -0,0 +1,137 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAbsPathRelativeToGofileDir(t *testing.T) {
+	p := Packages{dir: "/src/project"}
+	got, err := p.absPath("../jid")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/src/jid", got)
+}
+
+func TestAbsPathKeepsAbsolutePath(t *testing.T) {
+	p := Packages{dir: "/src/project"}
+	got, err := p.absPath("/opt/jid")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/opt/jid", got)
+}
+
+func TestWriteModfile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	checkout := filepath.Join(dir, "jid")
+	os.Mkdir(checkout, 0755)
+	ioutil.WriteFile(filepath.Join(checkout, "go.mod"), []byte("module github.com/simeji/jid\n"), 0644)
+	ioutil.WriteFile(filepath.Join(checkout, "go.sum"), []byte("sum\n"), 0644)
+
+	os.MkdirAll(filepath.Join(checkout, "cmd", "jid"), 0755)
+
+	step := &Step{
+		Path: filepath.Join(checkout, "cmd", "jid"),
+		Replace: map[string]string{
+			"github.com/nsf/termbox-go":      "/src/termbox-go",
+			"github.com/bitly/go-simplejson": "/src/go-simplejson",
+		},
+		Modfile: filepath.Join(dir, "go.mod"),
+	}
+	err := writeModfile(step)
+	want := `module github.com/simeji/jid
+
+replace github.com/bitly/go-simplejson => "/src/go-simplejson"
+replace github.com/nsf/termbox-go => "/src/termbox-go"
+`
+	got, _ := ioutil.ReadFile(step.Modfile)
+	sum, _ := ioutil.ReadFile(filepath.Join(dir, "go.sum"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(got))
+	assert.Equal(t, "sum\n", string(sum))
+}
+
+func TestWriteModfileReturnsErrorWithoutGoMod(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+
+	step := &Step{
+		Path:    dir,
+		Modfile: filepath.Join(dir, "modfile", "go.mod"),
+	}
+	err := writeModfile(step)
+
+	assert.Error(t, err)
+}
+
+func TestModuleRoot(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "cmd", "jid"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/simeji/jid\n"), 0644)
+
+	got, err := moduleRoot(filepath.Join(dir, "cmd", "jid"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, dir, got)
+}
+
+func TestLocalImportPath(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "checkout-fork", "cmd", "jid"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "checkout-fork", "go.mod"), []byte("// Fork of jid.\nmodule \"github.com/simeji/jid\"\n\ngo 1.20\n"), 0644)
+
+	got, err := localImportPath(filepath.Join(dir, "checkout-fork"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/simeji/jid", got)
+
+	got, err = localImportPath(filepath.Join(dir, "checkout-fork", "cmd", "jid"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/simeji/jid/cmd/jid", got)
+}
+
+func TestLocalImportPathWithoutGoMod(t *testing.T) {
+	got, err := localImportPath("/missing/jid")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "jid", got)
+}
+
+func TestLocalImportPathReturnsErrorWithoutModulePath(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("go 1.20\n"), 0644)
+
+	_, err := localImportPath(dir)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"debug/buildinfo"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/retr0h/gofile/utils"
+)
+
+var (
+	readBuildInfo = buildinfo.ReadFile
+)
+
+// Lock containing the `h1:` hashes of the module versions installed in module
+// mode, in the format of go.sum.
+type Lock struct {
+	sums map[string]string // sums keyed by "path version".
+}
+
+// LoadLock reads the lock from the named file, returning an empty lock when
+// the file does not exist.
+func LoadLock(filename string) (*Lock, error) {
+	l := &Lock{sums: make(map[string]string)}
+
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: malformed line", filename, n)
+		}
+		l.Add(fields[0], fields[1], fields[2])
+	}
+
+	return l, scanner.Err()
+}
+
+// Sum returns the hash locked for the provided module version.
+func (l *Lock) Sum(path string, version string) (string, bool) {
+	sum, ok := l.sums[path+" "+version]
+
+	return sum, ok
+}
+
+// Add locks the provided module version to the provided hash.
+func (l *Lock) Add(path string, version string, sum string) {
+	l.sums[path+" "+version] = sum
+}
+
+// Save writes the lock to the named file, sorted by module version.
+func (l *Lock) Save(filename string) error {
+	keys := make([]string, 0, len(l.sums))
+	for k := range l.sums {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s %s\n", k, l.sums[k])
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// locks returns true when the module of the provided step is verified
+// against `Packages.LockFile`.
+func (p *Packages) locks(step *Step) bool {
+	return p.LockFile != "" && step.Mode == ModeModule
+}
+
+// verifyModule compares the `h1:` hash of the module which built the provided
+// binary with the hash locked for its version.  Versions which are not locked
+// yet are added to the lock, unless offline, where the lock is the only source
+// of trusted hashes.
+func (p *Packages) verifyModule(binary string) error {
+	info, err := readBuildInfo(binary)
+	if err != nil {
+		return err
+	}
+	mod := info.Main
+
+	l, err := LoadLock(p.LockFile)
+	if err != nil {
+		return err
+	}
+
+	sum, ok := l.Sum(mod.Path, mod.Version)
+	switch {
+	case ok && sum != mod.Sum:
+		return fmt.Errorf("module %s@%s does not match %s: locked %s, got %s",
+			mod.Path, mod.Version, p.LockFile, sum, mod.Sum)
+	case ok:
+		p.log().Infof("Verified: %s %s %s\n", utils.Color.Cyan(mod.Path), mod.Version, mod.Sum)
+
+		return nil
+	case p.Offline:
+		return fmt.Errorf("module %s@%s is not locked in %s, which is required offline",
+			mod.Path, mod.Version, p.LockFile)
+	}
+
+	p.log().Infof("Locking: %s %s %s\n", utils.Color.Cyan(mod.Path), mod.Version, mod.Sum)
+	l.Add(mod.Path, mod.Version, mod.Sum)
+
+	return l.Save(p.LockFile)
+}
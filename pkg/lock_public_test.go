@@ -0,0 +1,113 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadLockWithMissingFile(t *testing.T) {
+	l, err := pkg.LoadLock("/missing/gofile.lock")
+	_, ok := l.Sum("github.com/simeji/jid", "v0.7.6")
+
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestLoadLockReturnsErrorWithMalformedFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "gofile.lock")
+	ioutil.WriteFile(filename, []byte("github.com/simeji/jid v0.7.6\n"), 0644)
+
+	_, err := pkg.LoadLock(filename)
+	want := filename + ":1: malformed line"
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestLockSave(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "gofile.lock")
+	l, _ := pkg.LoadLock(filename)
+	l.Add("golang.org/x/lint", "v0.0.0-20210508222113-6edffad5e616", "h1:rjwSpXsdiK0dV8/Naq3kAZ9ymfPoGjDfgd23LrZ2Ya4=")
+	l.Add("github.com/simeji/jid", "v0.7.6", "h1:a7J4Pi1X+Aa1MeDmqXLFrtVbHLaqfZvv2V3gZUHSZmI=")
+
+	err := l.Save(filename)
+	got, _ := ioutil.ReadFile(filename)
+	want := `github.com/simeji/jid v0.7.6 h1:a7J4Pi1X+Aa1MeDmqXLFrtVbHLaqfZvv2V3gZUHSZmI=
+golang.org/x/lint v0.0.0-20210508222113-6edffad5e616 h1:rjwSpXsdiK0dV8/Naq3kAZ9ymfPoGjDfgd23LrZ2Ya4=
+`
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(got))
+
+	loaded, _ := pkg.LoadLock(filename)
+	sum, ok := loaded.Sum("github.com/simeji/jid", "v0.7.6")
+
+	assert.True(t, ok)
+	assert.Equal(t, "h1:a7J4Pi1X+Aa1MeDmqXLFrtVbHLaqfZvv2V3gZUHSZmI=", sum)
+}
+
+func TestPlanOffline(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  version: v0.7.6
+  mode: module
+`
+	defer setenv("GOBIN", "/gobin")()
+	p := pkg.Packages{
+		Offline:  true,
+		ProxyDir: "/proxy",
+	}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Plan()
+	want := []string{"GOBIN=/gobin", "GOPROXY=file:///proxy", "GOSUMDB=off"}
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got[0].Command.Env)
+}
+
+func TestPlanOfflineWithoutProxyDir(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: module
+`
+	defer setenv("GOBIN", "/gobin")()
+	p := pkg.Packages{
+		Offline: true,
+	}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Plan()
+	want := []string{"GOBIN=/gobin", "GOPROXY=off", "GOSUMDB=off"}
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got[0].Command.Env)
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"debug/buildinfo"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const jidSum = "h1:a7J4Pi1X+Aa1MeDmqXLFrtVbHLaqfZvv2V3gZUHSZmI="
+
+func TestVerifyModuleLocksNewVersion(t *testing.T) {
+	defer stubReadBuildInfo(jidSum)()
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	p := Packages{LockFile: filepath.Join(dir, "gofile.lock")}
+
+	err := p.verifyModule("jid")
+	l, _ := LoadLock(p.LockFile)
+	sum, _ := l.Sum("github.com/simeji/jid", "v0.7.6")
+
+	assert.NoError(t, err)
+	assert.Equal(t, jidSum, sum)
+}
+
+func TestVerifyModule(t *testing.T) {
+	defer stubReadBuildInfo(jidSum)()
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	p := Packages{LockFile: filepath.Join(dir, "gofile.lock"), Offline: true}
+	ioutil.WriteFile(p.LockFile, []byte("github.com/simeji/jid v0.7.6 "+jidSum+"\n"), 0644)
+
+	err := p.verifyModule("jid")
+
+	assert.NoError(t, err)
+}
+
+func TestVerifyModuleReturnsErrorWithMismatch(t *testing.T) {
+	defer stubReadBuildInfo("h1:retagged=")()
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	p := Packages{LockFile: filepath.Join(dir, "gofile.lock")}
+	ioutil.WriteFile(p.LockFile, []byte("github.com/simeji/jid v0.7.6 "+jidSum+"\n"), 0644)
+
+	err := p.verifyModule("jid")
+	want := "module github.com/simeji/jid@v0.7.6 does not match " + p.LockFile +
+		": locked " + jidSum + ", got h1:retagged="
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestVerifyModuleReturnsErrorWhenOfflineAndNotLocked(t *testing.T) {
+	defer stubReadBuildInfo(jidSum)()
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	p := Packages{LockFile: filepath.Join(dir, "gofile.lock"), Offline: true}
+
+	err := p.verifyModule("jid")
+	want := "module github.com/simeji/jid@v0.7.6 is not locked in " + p.LockFile + ", which is required offline"
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestInstallRefusesModuleMismatch(t *testing.T) {
+	defer stubReadBuildInfo("h1:retagged=")()
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	t.Setenv("GOBIN", dir)
+	p := Packages{
+		Packages: []Package{{URL: "github.com/simeji/jid/cmd/jid", Version: "v0.7.6", Mode: ModeModule}},
+		LockFile: filepath.Join(dir, "gofile.lock"),
+		Runner:   &binaryRunner{},
+	}
+	ioutil.WriteFile(p.LockFile, []byte("github.com/simeji/jid v0.7.6 "+jidSum+"\n"), 0644)
+
+	err := p.Install(context.Background())
+
+	assert.Error(t, err)
+	_, statErr := os.Stat(filepath.Join(dir, "jid"))
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+// stubReadBuildInfo replaces reading the build info of binaries with a jid
+// build from a module with the provided hash, and returns a function
+// restoring the original.
+func stubReadBuildInfo(sum string) func() {
+	original := readBuildInfo
+	readBuildInfo = func(string) (*buildinfo.BuildInfo, error) {
+		return &buildinfo.BuildInfo{
+			Path:      "github.com/simeji/jid/cmd/jid",
+			GoVersion: "go1.20.3",
+			Main:      debug.Module{Path: "github.com/simeji/jid", Version: "v0.7.6", Sum: sum},
+		}, nil
+	}
+
+	return func() { readBuildInfo = original }
+}
+
+// binaryRunner writes an empty binary into the GOBIN of each command.
+type binaryRunner struct{}
+
+func (r *binaryRunner) Run(ctx context.Context, cmd *Command) error {
+	for _, e := range cmd.Env {
+		if strings.HasPrefix(e, "GOBIN=") {
+			gobin := strings.TrimPrefix(e, "GOBIN=")
+			os.MkdirAll(gobin, 0755)
+
+			return ioutil.WriteFile(filepath.Join(gobin, "jid"), nil, 0755)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"sync"
+)
+
+// Observer is the interface of the lifecycle of installs.
+//
+// OnStart is called when a package starts to install, and OnOutput with
+// the output of the commands it runs with the default `Runner`.  Then
+// OnSuccess or OnFailure is called once installed, with the duration of the
+// install and the error which failed it.  OnSkip is called instead of
+// OnStart when a package is up to date.  Installs are sequential, and the
+// output of a command's stdout and stderr is serialized, so implementations
+// are called from one goroutine at a time.
+type Observer interface {
+	OnStart(e *Event)
+	OnOutput(e *Event)
+	OnSuccess(e *Event)
+	OnFailure(e *Event)
+	OnSkip(e *Event)
+}
+
+// PlanObserver is implemented by observers notified of the planned steps
+// before any package is installed, e.g. to display every package queued.
+type PlanObserver interface {
+	OnPlan(steps []*Step)
+}
+
+// FinishObserver is implemented by observers notified once installs finish,
+// with the result of each package and the error which stopped installing,
+// e.g. to summarize the installs.  An error returned fails the install.
+type FinishObserver interface {
+	OnFinish(results []*Result, err error) error
+}
+
+// ObserverFuncs implements `Observer` with a function per event, any of
+// which may be nil.
+type ObserverFuncs struct {
+	Start   func(e *Event)
+	Output  func(e *Event)
+	Success func(e *Event)
+	Failure func(e *Event)
+	Skip    func(e *Event)
+}
+
+// OnStart calls the `Start` hook.
+func (h *ObserverFuncs) OnStart(e *Event) { call(h.Start, e) }
+
+// OnOutput calls the `Output` hook.
+func (h *ObserverFuncs) OnOutput(e *Event) { call(h.Output, e) }
+
+// OnSuccess calls the `Success` hook.
+func (h *ObserverFuncs) OnSuccess(e *Event) { call(h.Success, e) }
+
+// OnFailure calls the `Failure` hook.
+func (h *ObserverFuncs) OnFailure(e *Event) { call(h.Failure, e) }
+
+// OnSkip calls the `Skip` hook.
+func (h *ObserverFuncs) OnSkip(e *Event) { call(h.Skip, e) }
+
+// call calls the provided hook, unless nil.
+func call(hook func(e *Event), e *Event) {
+	if hook != nil {
+		hook(e)
+	}
+}
+
+// observers notifies each observer of an event, in order.
+type observers []Observer
+
+// notify passes the provided event to the method of each observer handling
+// its type.
+func (obs observers) notify(e *Event) {
+	e.Time = now().UTC()
+
+	for _, o := range obs {
+		switch e.Type {
+		case EventStart:
+			o.OnStart(e)
+		case EventOutput:
+			o.OnOutput(e)
+		case EventSuccess:
+			o.OnSuccess(e)
+		case EventFailure:
+			o.OnFailure(e)
+		case EventUpToDate:
+			o.OnSkip(e)
+		}
+	}
+}
+
+// plan notifies the observers implementing `PlanObserver` of the planned
+// steps.
+func (obs observers) plan(steps []*Step) {
+	for _, o := range obs {
+		if po, ok := o.(PlanObserver); ok {
+			po.OnPlan(steps)
+		}
+	}
+}
+
+// finish notifies the observers implementing `FinishObserver` that installs
+// finished, returning the first error they return.
+func (obs observers) finish(results []*Result, err error) error {
+	var finishErr error
+	for _, o := range obs {
+		if fo, ok := o.(FinishObserver); ok {
+			if err := fo.OnFinish(results, err); err != nil && finishErr == nil {
+				finishErr = err
+			}
+		}
+	}
+
+	return finishErr
+}
+
+// outputWriter notifies observers of the output of the step's commands.
+// The same writer receives stdout and stderr, which os/exec copies on
+// separate goroutines, so writes are serialized.
+type outputWriter struct {
+	obs  observers
+	step *Step
+	mu   sync.Mutex
+}
+
+// Write notifies the observers of the written output.
+func (w *outputWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.obs.notify(&Event{
+		Type:    EventOutput,
+		Package: w.step.Name(),
+		Binary:  w.step.Binary,
+		Output:  string(b),
+		step:    w.step,
+	})
+
+	return len(b), nil
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/retr0h/gofile/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordEvents returns observer funcs recording each event.
+func recordEvents(events *[]*pkg.Event) *pkg.ObserverFuncs {
+	record := func(e *pkg.Event) { *events = append(*events, e) }
+
+	return &pkg.ObserverFuncs{Start: record, Output: record, Success: record, Failure: record, Skip: record}
+}
+
+func TestInstallNotifiesObservers(t *testing.T) {
+	defer setenv("GOBIN", "/gobin")()
+	var got []*pkg.Event
+	p := pkg.Packages{
+		Packages:  []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Mode: pkg.ModeGOPATH}},
+		Logger:    &utils.Logger{Level: utils.LevelError},
+		Runner:    &fakeRunner{},
+		Observers: []pkg.Observer{recordEvents(&got)},
+	}
+	err := p.Install(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, pkg.EventStart, got[0].Type)
+	assert.Equal(t, "github.com/simeji/jid/cmd/jid", got[0].Package)
+	assert.Equal(t, "/gobin/jid", got[0].Binary)
+	assert.Equal(t, pkg.EventSuccess, got[1].Type)
+	assert.NoError(t, got[1].Err)
+}
+
+func TestInstallNotifiesObserversOfFailure(t *testing.T) {
+	defer setenv("GOBIN", "/gobin")()
+	var got []*pkg.Event
+	p := pkg.Packages{
+		Packages:  []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Mode: pkg.ModeGOPATH}},
+		Logger:    &utils.Logger{Level: utils.LevelError},
+		Runner:    &fakeRunner{err: errors.New("exit status 1")},
+		Observers: []pkg.Observer{recordEvents(&got)},
+	}
+	err := p.Install(context.Background())
+
+	assert.Error(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, pkg.EventFailure, got[1].Type)
+	assert.Equal(t, err, got[1].Err)
+	assert.Equal(t, err.Error(), got[1].Error)
+}
+
+func TestInstallNotifiesObserversOfSkip(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	ioutil.WriteFile(filepath.Join(dir, "jid"), []byte("github.com/simeji/jid/cmd/jid"), 0755)
+	var got []*pkg.Event
+	p := pkg.Packages{
+		Packages: []pkg.Package{{
+			URL:    "github.com/simeji/jid/cmd/jid",
+			Mode:   pkg.ModeGOPATH,
+			SHA256: "a8f7cd6a1e16e21aa52b61f7047127785867bbb982d441ceef2005355bec94b0",
+		}},
+		Logger:    &utils.Logger{Level: utils.LevelError},
+		Runner:    &fakeRunner{},
+		Observers: []pkg.Observer{recordEvents(&got)},
+	}
+	err := p.Install(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, pkg.EventUpToDate, got[0].Type)
+}
+
+func TestObserverFuncsIgnoresNilFuncs(t *testing.T) {
+	h := &pkg.ObserverFuncs{}
+
+	assert.NotPanics(t, func() {
+		h.OnStart(&pkg.Event{})
+		h.OnOutput(&pkg.Event{})
+		h.OnSuccess(&pkg.Event{})
+		h.OnFailure(&pkg.Event{})
+		h.OnSkip(&pkg.Event{})
+	})
+}
+
+// lifecycleObserver records the planned steps and the finished results.
+type lifecycleObserver struct {
+	pkg.ObserverFuncs
+	steps   []*pkg.Step
+	results []*pkg.Result
+	err     error
+}
+
+func (o *lifecycleObserver) OnPlan(steps []*pkg.Step) { o.steps = steps }
+
+func (o *lifecycleObserver) OnFinish(results []*pkg.Result, err error) error {
+	o.results = results
+	o.err = err
+
+	return nil
+}
+
+func TestApplyNotifiesPlanAndFinishObservers(t *testing.T) {
+	defer setenv("GOBIN", "/gobin")()
+	o := &lifecycleObserver{}
+	p := pkg.Packages{
+		Packages:  []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Mode: pkg.ModeGOPATH}},
+		Logger:    &utils.Logger{Level: utils.LevelError},
+		Runner:    &fakeRunner{err: errors.New("exit status 1")},
+		Observers: []pkg.Observer{o},
+	}
+	results, err := p.Apply(context.Background())
+
+	assert.Error(t, err)
+	assert.Len(t, o.steps, 1)
+	assert.Equal(t, results, o.results)
+	assert.Equal(t, err, o.err)
+}
+
+func TestApplyReturnsFinishObserverError(t *testing.T) {
+	p := pkg.Packages{
+		Packages:  []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Mode: pkg.ModeGOPATH}},
+		Logger:    &utils.Logger{Level: utils.LevelError},
+		Runner:    &fakeRunner{},
+		Observers: []pkg.Observer{pkg.NewEventWriter(failingWriter{}, pkg.FormatJSON)},
+	}
+	_, err := p.Apply(context.Background())
+
+	assert.EqualError(t, err, "write failed")
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
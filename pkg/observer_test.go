@@ -0,0 +1,78 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/retr0h/gofile/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunnerNotifiesObserversOfOutput(t *testing.T) {
+	var got []*Event
+	observer := &ObserverFuncs{Output: func(e *Event) { got = append(got, e) }}
+	step := &Step{URL: "github.com/simeji/jid/cmd/jid", Binary: "/gobin/jid"}
+	p := Packages{Observers: []Observer{observer}}
+	p.installing = step
+
+	r := p.runner().(*ExecRunner)
+	io.WriteString(r.Stdout, "building\n")
+	io.WriteString(r.Stderr, "warning\n")
+
+	assert.Len(t, got, 2)
+	assert.Equal(t, EventOutput, got[0].Type)
+	assert.Equal(t, "github.com/simeji/jid/cmd/jid", got[0].Package)
+	assert.Equal(t, "building\n", got[0].Output)
+	assert.Equal(t, "warning\n", got[1].Output)
+}
+
+func TestRunnerDoesNotObserveOutputWithoutObservers(t *testing.T) {
+	p := Packages{}
+	p.installing = &Step{URL: "github.com/simeji/jid/cmd/jid"}
+
+	r := p.runner().(*ExecRunner)
+
+	assert.Nil(t, r.Stdout)
+}
+
+func TestRunnerSerializesObservedOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("runs sh")
+	}
+	var got []string
+	observer := &ObserverFuncs{Output: func(e *Event) { got = append(got, e.Output) }}
+	step := &Step{URL: "github.com/simeji/jid/cmd/jid", Binary: "/gobin/jid"}
+	p := Packages{Logger: &utils.Logger{Level: utils.LevelError}, Observers: []Observer{observer}}
+	p.installing = step
+
+	// os/exec copies stdout and stderr on their own goroutines, which race
+	// without serializing the observed output.
+	script := "for i in 1 2 3 4 5 6 7 8 9 10; do echo out; echo err >&2; done"
+	err := p.runner().Run(context.Background(), &Command{Name: "sh", Args: []string{"-c", script}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 20, strings.Count(strings.Join(got, ""), "\n"))
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/retr0h/gofile/utils"
+)
+
+// Option configures the `Packages` returned by `Load`.
+type Option func(*Packages)
+
+// Load reads the gofile named by `filename`, returning its packages
+// configured by the provided options.  Unlike a zero `Packages`, nothing is
+// logged or written unless a logger or output writer is provided, so
+// gofile can be embedded by other programs.
+func Load(filename string, opts ...Option) (*Packages, error) {
+	p := &Packages{
+		Logger: &utils.Logger{Level: utils.LevelInfo, Out: ioutil.Discard, Err: ioutil.Discard},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if err := p.UnmarshalYAMLFile(filename); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// WithRunner executes commands with the provided runner.
+func WithRunner(r Runner) Option {
+	return func(p *Packages) {
+		p.Runner = r
+	}
+}
+
+// WithLogger logs progress, commands, and their output to the provided
+// logger.
+func WithLogger(l *utils.Logger) Option {
+	return func(p *Packages) {
+		p.Logger = l
+	}
+}
+
+// WithOutput writes the events of installs to w in the provided format,
+// with an `EventWriter`.
+func WithOutput(w io.Writer, format string) Option {
+	return WithObserver(NewEventWriter(w, format))
+}
+
+// WithTimeout bounds each install by the provided timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Packages) {
+		p.Timeout = timeout
+	}
+}
+
+// WithRetries retries installs failing with a transient error.
+func WithRetries(retries int) Option {
+	return func(p *Packages) {
+		p.Retries = retries
+	}
+}
+
+// WithIsolated builds each package in a throwaway GOPATH, persisting the
+// module and build caches in cacheDir when not empty.
+func WithIsolated(cacheDir string) Option {
+	return func(p *Packages) {
+		p.Isolated = true
+		p.CacheDir = cacheDir
+	}
+}
+
+// WithStateFile records installed binaries in the provided file.
+func WithStateFile(stateFile string) Option {
+	return func(p *Packages) {
+		p.StateFile = stateFile
+	}
+}
+
+// WithLockFile verifies modules against the hashes in the provided lockfile.
+func WithLockFile(lockFile string) Option {
+	return func(p *Packages) {
+		p.LockFile = lockFile
+	}
+}
+
+// WithOffline installs modules only from the module proxy in proxyDir.
+func WithOffline(proxyDir string) Option {
+	return func(p *Packages) {
+		p.Offline = true
+		p.ProxyDir = proxyDir
+	}
+}
+
+// WithStore keeps keep binaries per package in storeDir for rollbacks.
+func WithStore(storeDir string, keep int) Option {
+	return func(p *Packages) {
+		p.StoreDir = storeDir
+		p.Keep = keep
+	}
+}
+
+// WithForce reinstalls packages which are up to date.
+func WithForce(force bool) Option {
+	return func(p *Packages) {
+		p.Force = force
+	}
+}
+
+// WithCompletions generates completion scripts for the provided shells
+// into dir, for all `Shells` when none are provided.
+func WithCompletions(dir string, shells ...string) Option {
+	return func(p *Packages) {
+		p.CompletionDir = dir
+		p.CompletionShells = shells
+	}
+}
+
+// WithToolsDir caches the tools built by `Tool` in the provided directory.
+func WithToolsDir(toolsDir string) Option {
+	return func(p *Packages) {
+		p.ToolsDir = toolsDir
+	}
+}
+
+// WithContinueOnError installs the remaining packages when a package fails.
+func WithContinueOnError(continueOnError bool) Option {
+	return func(p *Packages) {
+		p.ContinueOnError = continueOnError
+	}
+}
+
+// WithObserver notifies the provided observer of the lifecycle of installs.
+func WithObserver(o Observer) Option {
+	return func(p *Packages) {
+		p.Observers = append(p.Observers, o)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path"
+	"testing"
+
+	capturer "github.com/kami-zh/go-capturer"
+	"github.com/retr0h/gofile/pkg"
+	"github.com/retr0h/gofile/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	r := &fakeRunner{}
+	l := &utils.Logger{}
+	p, err := pkg.Load(path.Join("..", "test", "gofile.yml"),
+		pkg.WithRunner(r),
+		pkg.WithLogger(l),
+		pkg.WithRetries(2),
+		pkg.WithForce(true),
+	)
+
+	assert.NoError(t, err)
+	assert.Len(t, p.Packages, 4)
+	assert.Equal(t, r, p.Runner)
+	assert.Equal(t, l, p.Logger)
+	assert.Equal(t, 2, p.Retries)
+	assert.True(t, p.Force)
+}
+
+func TestLoadReturnsErrorWithMissingFile(t *testing.T) {
+	_, err := pkg.Load("/missing/gofile.yml")
+
+	var manifestErr *pkg.ManifestError
+	assert.True(t, errors.As(err, &manifestErr))
+}
+
+func TestLoadWritesNothingByDefault(t *testing.T) {
+	defer setenv("GOBIN", "/gobin")()
+	p, err := pkg.Load(path.Join("..", "test", "gofile.yml"), pkg.WithRunner(&versionRunner{Runner: &fakeRunner{}, out: goVersionOut}))
+	assert.NoError(t, err)
+
+	got := capturer.CaptureOutput(func() {
+		results, err := p.Apply(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, results, 4)
+	})
+
+	assert.Empty(t, got)
+}
+
+func TestLoadWithOutput(t *testing.T) {
+	defer setenv("GOBIN", "/gobin")()
+	var buf bytes.Buffer
+	p, err := pkg.Load(path.Join("..", "test", "gofile.yml"),
+		pkg.WithRunner(&versionRunner{Runner: &fakeRunner{}, out: goVersionOut}),
+		pkg.WithOutput(&buf, pkg.FormatNDJSON),
+	)
+	assert.NoError(t, err)
+
+	_, err = p.Apply(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 8, bytes.Count(buf.Bytes(), []byte("\n")))
+}
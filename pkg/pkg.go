@@ -23,22 +23,36 @@
 package pkg
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
-	"strings"
+	"path/filepath"
 	"time"
 
-	"github.com/caarlos0/spin"
 	"github.com/ghodss/yaml"
 	"github.com/logrusorgru/aurora"
+	"github.com/retr0h/gofile/utils"
 	"github.com/xeipuuv/gojsonschema"
 )
 
-const pkgSchema = `
+// hooksSchema validates the commands run before or after installing.
+const hooksSchema = `
+{
+  "type": "array",
+  "items": {
+    "type": "string",
+    "minLength": 1
+  }
+}
+`
+
+// pkgSchema validates gofiles written as a list of packages.
+var pkgSchema = fmt.Sprintf(`
 {
   "type": "array",
   "$schema": "http://json-schema.org/draft-04/schema#",
@@ -47,33 +61,179 @@ const pkgSchema = `
   "uniqueItems": true,
   "items": {
     "type": "object",
-    "required": [
-      "url"
+    "oneOf": [
+      {
+        "required": [
+          "url"
+        ]
+      },
+      {
+        "required": [
+          "path"
+        ]
+      },
+      {
+        "required": [
+          "release"
+        ]
+      }
     ],
+    "dependencies": {
+      "replace": [
+        "path"
+      ]
+    },
     "properties": {
       "url": {
         "type": "string"
-      }
+      },
+      "path": {
+        "type": "string"
+      },
+      "version": {
+        "type": "string"
+      },
+      "go": {
+        "type": "string"
+      },
+      "mode": {
+        "type": "string",
+        "enum": [
+          "module",
+          "gopath"
+        ]
+      },
+      "release": {
+        "type": "object",
+        "required": [
+          "url",
+          "binary"
+        ],
+        "additionalProperties": false,
+        "properties": {
+          "url": {
+            "type": "string"
+          },
+          "checksums": {
+            "type": "string"
+          },
+          "binary": {
+            "type": "string"
+          }
+        }
+      },
+      "replace": {
+        "type": "object",
+        "additionalProperties": {
+          "type": "string"
+        }
+      },
+      "sha256": {
+        "type": "string",
+        "pattern": "^[a-fA-F0-9]{64}$"
+      },
+      "retries": {
+        "type": "integer",
+        "minimum": 0
+      },
+      "timeout": {
+        "type": "string",
+        "pattern": "^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"
+      },
+      "completion": {
+        "type": "string",
+        "minLength": 1
+      },
+      "pre_install": %[1]s,
+      "post_install": %[1]s
     }
   }
 }
-`
+`, hooksSchema)
+
+// manifestSchema validates gofiles written as a mapping of options, with the
+// packages nested under the `packages` key.
+var manifestSchema = fmt.Sprintf(`
+{
+  "type": "object",
+  "$schema": "http://json-schema.org/draft-04/schema#",
+  "description": "",
+  "required": [
+    "packages"
+  ],
+  "additionalProperties": false,
+  "properties": {
+    "go": {
+      "type": "string"
+    },
+    "pre_install": %[1]s,
+    "post_install": %[1]s,
+    "packages": %[2]s
+  }
+}
+`, hooksSchema, pkgSchema)
 
 var (
 	jsonSchemaValidator = gojsonschema.Validate
 )
 
 // Package containing the go package details.  All fields are required unless
-// otherwise specified.
+// otherwise specified.  Exactly one of `URL`, `Path` or `Release` must be set.
 type Package struct {
-	URL string `yaml:"url"`
+	URL         string            `yaml:"url"`
+	Path        string            `yaml:"path"`                             // Path to a local checkout installed with `go install`.
+	Release     *Release          `yaml:"release"`                          // Release of a prebuilt binary to download.
+	Replace     map[string]string `yaml:"replace"`                          // Optional modules replaced with local paths, requires `Path`.
+	Version     string            `yaml:"version"`                          // Optional module or release version, defaults to latest.
+	Mode        string            `yaml:"mode"`                             // Optional install mode, detected from the toolchain.
+	Go          string            `yaml:"go"`                               // Optional toolchain overriding `Packages.Go`.
+	SHA256      string            `yaml:"sha256"`                           // Optional SHA-256 of the release asset, or the built binary.
+	Timeout     string            `yaml:"timeout"`                          // Optional duration overriding `Packages.Timeout`.
+	Retries     *int              `yaml:"retries"`                          // Optional retries overriding `Packages.Retries`.
+	Completion  string            `yaml:"completion"`                       // Optional arguments generating completion scripts, with `{shell}` replaced by the shell.
+	PreInstall  []string          `yaml:"pre_install" json:"pre_install"`   // Optional commands run before installing.
+	PostInstall []string          `yaml:"post_install" json:"post_install"` // Optional commands run once installed.
 }
 
 // Packages contains a list of `Package` structs initialized by the cli
-// via the `--filename` flag.
+// via the `--filename` flag, or by `Load`.
 type Packages struct {
-	Packages []Package
-	Debug    bool // Debug option set from CLI with debug state.
+	Packages         []Package
+	Go               string        // Go toolchain from the gofile, a path to `go` or a version.
+	PreInstall       []string      // PreInstall commands from the gofile, run before installing each package.
+	PostInstall      []string      // PostInstall commands from the gofile, run once each package is installed.
+	Logger           *utils.Logger // Logger of progress, commands, and their output, info to os.Stdout when nil.
+	Runner           Runner        // Runner executing commands, defaults to an `ExecRunner`.
+	Timeout          time.Duration // Timeout of each install, no timeout when zero.
+	Retries          int           // Retries of an install failing with a transient error.
+	Backoff          time.Duration // Backoff before the first retry, doubling for each retry.
+	Isolated         bool          // Isolated builds each package in a throwaway GOPATH.
+	CacheDir         string        // CacheDir persisting the module and build caches of isolated builds.
+	StateFile        string        // StateFile recording installed binaries, not recorded when empty.
+	LockFile         string        // LockFile of module hashes, modules are not verified when empty.
+	Offline          bool          // Offline installs modules only from `ProxyDir`, verified by the lock.
+	ProxyDir         string        // ProxyDir containing a module proxy used when offline.
+	StoreDir         string        // StoreDir keeping installed binaries for rollbacks, not kept when empty.
+	CompletionDir    string        // CompletionDir receiving completion scripts, not generated when empty.
+	CompletionShells []string      // CompletionShells completion scripts are generated for, defaults to `Shells`.
+	ToolsDir         string        // ToolsDir caching the tools built by `Tool`, defaults to `DefaultToolsDir`.
+	Force            bool          // Force reinstalls packages which are up to date.
+	ContinueOnError  bool          // ContinueOnError installs the remaining packages when a package fails.
+	Keep             int           // Keep binaries per package in `StoreDir`, including the installed binary.
+	Observers        []Observer    // Observers of the lifecycle of installs.
+	dir              string        // Directory of the gofile, which relative paths are relative to.
+	file             string        // Path of the gofile, recorded as the manifest of installed binaries.
+	installing       *Step         // Step being installed, whose output is observed.
+	toolDir          string        // Directory tools are built into, rather than the toolchain's bin directory.
+}
+
+// manifest containing the options and packages of a gofile written as a
+// mapping, rather than a list of packages.
+type manifest struct {
+	Go          string    `json:"go"`
+	PreInstall  []string  `json:"pre_install"`
+	PostInstall []string  `json:"post_install"`
+	Packages    []Package `json:"packages"`
 }
 
 // UnmarshalYAML decodes the first YAML document found within the data byte
@@ -92,8 +252,20 @@ func (p *Packages) UnmarshalYAML(data []byte) error {
 	}
 
 	// Unmarshal the jsonData to the `Packages` struct.
-	err = json.Unmarshal(jsonData, &p.Packages)
-	return err
+	if !isObject(jsonData) {
+		return json.Unmarshal(jsonData, &p.Packages)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(jsonData, &m); err != nil {
+		return err
+	}
+	p.Go = m.Go
+	p.PreInstall = m.PreInstall
+	p.PostInstall = m.PostInstall
+	p.Packages = m.Packages
+
+	return nil
 }
 
 // UnmarshalYAMLFile reads the file named by `filename` and passes the source
@@ -102,17 +274,32 @@ func (p *Packages) UnmarshalYAMLFile(filename string) error {
 	// Open and Read the provided filename.
 	source, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return err
+		return &ManifestError{File: filename, Err: err}
+	}
+
+	// Relative paths within the file are relative to its directory.
+	p.dir = filepath.Dir(filename)
+	if p.file, err = filepath.Abs(filename); err != nil {
+		return &ManifestError{File: filename, Err: err}
 	}
 
 	// Unmarshal the file contents.
-	err = p.UnmarshalYAML([]byte(source))
-	return err
+	if err := p.UnmarshalYAML([]byte(source)); err != nil {
+		return &ManifestError{File: filename, Err: err}
+	}
+
+	return nil
 }
 
-// Validate the the data byte slice against the `pkgSchema`.
+// Validate the the data byte slice against the `pkgSchema`, or the
+// `manifestSchema` when the gofile is a mapping.
 func (p *Packages) validate(data []byte) error {
-	schemaLoader := gojsonschema.NewStringLoader(pkgSchema)
+	schema := pkgSchema
+	if isObject(data) {
+		schema = manifestSchema
+	}
+
+	schemaLoader := gojsonschema.NewStringLoader(schema)
 	documentLoader := gojsonschema.NewBytesLoader(data)
 
 	// Validate the document against the schema.
@@ -129,53 +316,285 @@ func (p *Packages) validate(data []byte) error {
 			errstrings = append(errstrings, err.Error())
 		}
 
-		return errors.New(strings.Join(errstrings, "\n"))
+		return &ValidationError{Errors: errstrings}
 	}
 
 	return nil
 }
 
+// isObject returns true when the provided JSON document is an object.
+func isObject(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// Result of installing a package, returned by `Apply`.
+type Result struct {
+	Package  string        // Package installed.
+	Binary   string        // Binary installed.
+	State    string        // State of the package, `StateQueued` when not installed.
+	Duration time.Duration // Duration of the install, zero when up to date.
+	Err      error         // Err which failed the install.
+}
+
 // Install loops through the `Packages` struct and calls `go get` against
-// the resulting package.
-func (p *Packages) Install() error {
-	for _, pkg := range p.Packages {
-		if !p.Debug {
-			s := spin.New("%s ")
-			s.Set(spin.Spin8)
-			s.Start()
-			defer s.Stop()
-			// Allow the spinner to show when the install returns too quickly.
-			time.Sleep(5 * time.Millisecond)
+// the resulting package, skipping packages which are up to date unless
+// forced.  Installing stops when the provided context is
+// done, or a package takes longer than its timeout.
+func (p *Packages) Install(ctx context.Context) error {
+	_, err := p.Apply(ctx)
+
+	return err
+}
+
+// Apply installs the packages like `Install`, returning the result of each
+// planned package.  Packages after a failed package are not installed, and
+// are returned queued, unless continuing on error.
+func (p *Packages) Apply(ctx context.Context) (results []*Result, err error) {
+	obs := observers(p.Observers)
+	defer func() {
+		if finishErr := obs.finish(results, err); err == nil {
+			err = finishErr
 		}
-		fmt.Printf("Installing: %s\n", aurora.Cyan(pkg.URL))
+	}()
+
+	steps, err := p.plan(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-		goCmdArgs := []string{"get"}
-		if p.Debug {
-			goCmdArgs = append(goCmdArgs, "-v")
+	state := &State{}
+	if !p.Force && p.StateFile != "" {
+		if state, err = LoadState(p.StateFile); err != nil {
+			return nil, err
 		}
-		goCmdArgs = append(goCmdArgs, pkg.URL)
-		if err := p.RunCmd("go", goCmdArgs...); err != nil {
+	}
+
+	for _, step := range steps {
+		results = append(results, &Result{Package: step.Name(), Binary: step.Binary, State: StateQueued})
+	}
+	obs.plan(steps)
+
+	// The first failure is returned once the remaining packages are
+	// installed, when continuing on error.
+	var failed error
+	for i, step := range steps {
+		if !p.Force && p.upToDate(ctx, step, state) {
+			results[i].State = StateUpToDate
+			obs.notify(&Event{Type: EventUpToDate, Package: step.Name(), Binary: step.Binary, step: step})
+			continue
+		}
+
+		start := time.Now()
+		err := p.installPackage(ctx, step)
+		results[i].Duration = time.Since(start)
+		if err != nil {
+			results[i].State = StateFailed
+			results[i].Err = err
+			if !p.ContinueOnError || ctx.Err() != nil {
+				return results, err
+			}
+			if failed == nil {
+				failed = err
+			}
+			continue
+		}
+		results[i].State = StateDone
+	}
+
+	return results, failed
+}
+
+// installPackage installs the provided step, notifying the observers when
+// it starts, and when it succeeds or fails.
+func (p *Packages) installPackage(ctx context.Context, step *Step) error {
+	observers(p.Observers).notify(&Event{Type: EventStart, Package: step.Name(), Binary: step.Binary, step: step})
+
+	p.installing = step
+	defer func() { p.installing = nil }()
+
+	start := time.Now()
+	err := p.installBinary(ctx, step)
+
+	var installErr *InstallError
+	if err != nil && ctx.Err() == nil && !errors.As(err, &installErr) {
+		err = &InstallError{Package: step.Name(), Attempts: 1, Err: err}
+	}
+
+	e := &Event{
+		Type:     EventSuccess,
+		Package:  step.Name(),
+		Binary:   step.Binary,
+		Duration: time.Since(start).Seconds(),
+		step:     step,
+	}
+	if err != nil {
+		e.Type = EventFailure
+		e.Error = err.Error()
+		e.Err = err
+
+		var cmdErr *CommandError
+		if errors.As(err, &cmdErr) {
+			e.Stderr = cmdErr.Stderr
+		}
+	}
+	observers(p.Observers).notify(e)
+
+	return err
+}
+
+// installBinary runs the pre-install hooks of the provided step, installs
+// its binary, keeps and records it, generates its completion scripts, then
+// runs the post-install hooks.
+func (p *Packages) installBinary(ctx context.Context, step *Step) error {
+	if step.TempDir != "" {
+		if err := os.Mkdir(step.TempDir, 0700); err != nil {
 			return err
 		}
+		defer os.RemoveAll(step.TempDir)
 	}
-	return nil
+
+	if step.Modfile != "" {
+		if err := writeModfile(step); err != nil {
+			return err
+		}
+	}
+
+	if err := p.runHooks(ctx, step, "pre-install", step.PreInstall); err != nil {
+		return err
+	}
+
+	var err error
+	if step.Release != nil {
+		err = p.installRelease(ctx, step)
+	} else if step.Stage != "" {
+		err = p.installStaged(ctx, step)
+	} else {
+		err = p.installStep(ctx, step)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := p.store(step); err != nil {
+		return err
+	}
+
+	if err := p.record(step); err != nil {
+		return err
+	}
+
+	if err := p.completions(ctx, step); err != nil {
+		return err
+	}
+
+	return p.runHooks(ctx, step, "post-install", step.PostInstall)
+}
+
+// installStep runs the command of the provided step, retrying transient
+// failures up to the step's retries.
+func (p *Packages) installStep(ctx context.Context, step *Step) error {
+	return p.retry(ctx, step, p.attemptStep)
+}
+
+// retry makes the provided attempt at installing the step, retrying
+// transient failures up to the step's retries with a backoff.
+func (p *Packages) retry(ctx context.Context, step *Step, attempt func(context.Context, *Step) error) error {
+	for retry := 0; ; retry++ {
+		err := attempt(ctx, step)
+		if err == nil || !isTransient(err) || retry >= step.Retries {
+			if err != nil && retry > 0 && ctx.Err() == nil {
+				return &InstallError{Package: step.Name(), Attempts: retry + 1, Err: err}
+			}
+			return err
+		}
+
+		delay := p.backoff(retry + 1)
+		p.log().Infof("Retrying: %s (attempt %d of %d) in %s\n",
+			utils.Color.Cyan(step.Name()), retry+2, step.Retries+1, delay)
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// attemptStep runs the command of the provided step once, bounded by the
+// step's timeout.
+func (p *Packages) attemptStep(ctx context.Context, step *Step) error {
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
+	}
+
+	err := p.runCommand(ctx, step.Command)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return &timeoutError{timeout: step.Timeout}
+	}
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
 }
 
 // RunCmd execute the provided command with args.
-func (p *Packages) RunCmd(name string, args ...string) error {
-	cmd := exec.Command(name, args...)
-	if p.Debug {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+func (p *Packages) RunCmd(ctx context.Context, name string, args ...string) error {
+	return p.runCommand(ctx, &Command{Name: name, Args: args})
+}
 
-		commands := strings.Join(cmd.Args, " ")
-		msg := fmt.Sprintf("COMMAND: %s", aurora.Colorize(commands, aurora.BlackFg|aurora.RedBg))
-		fmt.Println(msg)
+// runCommand executes the provided command with the configured `Runner`.
+func (p *Packages) runCommand(ctx context.Context, cmd *Command) error {
+	return p.runCommandAt(ctx, cmd, utils.LevelTrace)
+}
+
+// runCommandAt executes the provided command with the configured `Runner`,
+// logging the command's output at the provided level.
+func (p *Packages) runCommandAt(ctx context.Context, cmd *Command, level utils.Level) error {
+	p.log().Debugf("COMMAND: %s\n", utils.Color.Colorize(cmd.String(), aurora.BlackFg|aurora.RedBg))
+
+	return p.runnerAt(level).Run(ctx, cmd)
+}
+
+// runner returns the `Runner` configured on the struct, or an `ExecRunner`
+// which logs the command's output at the trace level, and passes it to the
+// observers.
+func (p *Packages) runner() Runner {
+	return p.runnerAt(utils.LevelTrace)
+}
+
+// runnerAt returns the `Runner` configured on the struct, or an `ExecRunner`
+// which logs the command's output at the provided level, and passes it to
+// the observers.
+func (p *Packages) runnerAt(level utils.Level) Runner {
+	if p.Runner != nil {
+		return p.Runner
 	}
 
-	if err := cmd.Run(); err != nil {
-		return err
+	r := &ExecRunner{
+		Stdout: p.log().Writer(level),
+		Stderr: p.log().ErrWriter(level),
 	}
 
-	return nil
+	// Observers receive the output of the package being installed, through
+	// one writer serializing stdout and stderr.
+	if p.installing != nil && len(p.Observers) > 0 {
+		w := &outputWriter{obs: p.Observers, step: p.installing}
+		r.Stdout = multiWriter(r.Stdout, w)
+		r.Stderr = multiWriter(r.Stderr, w)
+	}
+
+	return r
+}
+
+// multiWriter returns a writer duplicating writes to each of the provided
+// writers which are not nil.
+func multiWriter(writers ...io.Writer) io.Writer {
+	var ws []io.Writer
+	for _, w := range writers {
+		if w != nil {
+			ws = append(ws, w)
+		}
+	}
+
+	return io.MultiWriter(ws...)
 }
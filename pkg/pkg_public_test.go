@@ -21,14 +21,20 @@
 package pkg_test
 
 import (
+	"context"
 	"errors"
+	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	capturer "github.com/kami-zh/go-capturer"
 	"github.com/retr0h/gofile/pkg"
+	"github.com/retr0h/gofile/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -52,7 +58,7 @@ func TestUnmarshalYAMLDoesNotValidateYAMLAndReturnsError(t *testing.T) {
 foo: bar
 `
 	err := p.UnmarshalYAML([]byte(data))
-	want := errors.New("(root): Invalid type. Expected: array, given: object")
+	want := &pkg.ValidationError{Errors: []string{"packages: packages is required", "foo: Additional property foo is not allowed"}}
 
 	assert.Equal(t, want, err)
 }
@@ -69,6 +75,37 @@ func TestUnmarshalYAML(t *testing.T) {
 	assert.Equal(t, want, p.Packages[0].URL)
 }
 
+func TestUnmarshalYAMLManifest(t *testing.T) {
+	data := `
+---
+go: go1.20
+packages:
+  - url: github.com/simeji/jid/cmd/jid
+    go: /usr/local/go/bin/go
+`
+	p := pkg.Packages{}
+	err := p.UnmarshalYAML([]byte(data))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "go1.20", p.Go)
+	assert.Equal(t, "github.com/simeji/jid/cmd/jid", p.Packages[0].URL)
+	assert.Equal(t, "/usr/local/go/bin/go", p.Packages[0].Go)
+}
+
+func TestUnmarshalYAMLReturnsErrorWithEmptyHook(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  post_install:
+    - ""
+`
+	p := pkg.Packages{}
+	err := p.UnmarshalYAML([]byte(data))
+
+	var validationErr *pkg.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+}
+
 func TestUnmarshalYAMLFileReturnsErrorWithMissingFile(t *testing.T) {
 	filename := "missing.yml"
 
@@ -90,54 +127,541 @@ func TestInstall(t *testing.T) {
 	data := `
 ---
 - url: github.com/golang/example/hello
+  version: v0.1.0
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
 `
-	pkgDir := getGolangExamplePackageDir()
+	defer setenv("GOBIN", "/gobin")()
+	defer setenv("GO111MODULE", "on")()
+	r := &pkg.RecordRunner{}
+	p := pkg.Packages{
+		Runner: &versionRunner{Runner: r, out: goVersionOut},
+	}
 	p.UnmarshalYAML([]byte(data))
-	err := p.Install()
+	err := p.Install(context.Background())
+	want := []*pkg.Command{
+		{
+			Name: "go",
+			Args: []string{"install", "github.com/golang/example/hello@v0.1.0"},
+			Env:  []string{"GOBIN=/gobin"},
+		},
+		{
+			Name: "go",
+			Args: []string{"get", "github.com/simeji/jid/cmd/jid"},
+			Env:  []string{"GOBIN=/gobin", "GO111MODULE=off"},
+		},
+	}
 
 	assert.NoError(t, err)
-	assert.DirExists(t, pkgDir)
-
-	defer os.RemoveAll(pkgDir)
+	assert.Equal(t, want, r.Commands)
 }
 
 func TestInstallDebugAddsVFlag(t *testing.T) {
 	data := `
 ---
 - url: github.com/golang/example/hello
+  mode: gopath
 `
 	p := pkg.Packages{
-		Debug: true,
+		Logger: &utils.Logger{Level: utils.LevelTrace},
+		Runner: &pkg.RecordRunner{},
 	}
 	p.UnmarshalYAML([]byte(data))
 	got := capturer.CaptureStdout(func() {
-		err := p.Install()
+		err := p.Install(context.Background())
 		assert.NoError(t, err)
 	})
-	want := "Installing: \x1b[36mgithub.com/golang/example/hello\x1b[0m\nCOMMAND: \x1b[30;41mgo get -v github.com/golang/example/hello\x1b[0m\n"
+	want := "COMMAND: go get -v github.com/golang/example/hello\n"
 
 	assert.Equal(t, want, got)
+}
+
+func TestInstallLocalPathWritesModfile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/simeji/jid\n"), 0644)
+	data := `
+---
+- path: ` + dir + `
+  replace:
+    github.com/nsf/termbox-go: /src/termbox-go
+`
+	r := &modfileRunner{}
+	p := pkg.Packages{
+		Runner: r,
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+	modfile := strings.TrimPrefix(r.Commands[0].Args[1], "-modfile=")
+
+	assert.NoError(t, err)
+	assert.Equal(t, dir, r.Commands[0].Dir)
+	assert.Contains(t, r.modfile, "replace github.com/nsf/termbox-go => \"/src/termbox-go\"")
+	assert.Equal(t, os.FileMode(0700), r.perm)
+	assert.False(t, fileExists(filepath.Dir(modfile)))
+}
+
+func TestInstallIsolated(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+`
+	r := &buildRunner{}
+	p := pkg.Packages{
+		Isolated: true,
+		Runner:   &versionRunner{Runner: r, out: goVersionOut},
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+	got, _ := ioutil.ReadFile(filepath.Join(dir, "jid"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/simeji/jid/cmd/jid", string(got))
+	assert.NotEqual(t, dir, r.gobin)
+	assert.False(t, fileExists(r.gobin), "isolated GOPATH was removed")
+}
+
+func TestInstallVerifiesBinaryChecksum(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	stateFile := filepath.Join(dir, "state", "state.json")
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  sha256: a8f7cd6a1e16e21aa52b61f7047127785867bbb982d441ceef2005355bec94b0
+`
+	p := pkg.Packages{
+		Runner:    &buildRunner{},
+		StateFile: stateFile,
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+	state, _ := pkg.LoadState(stateFile)
+
+	assert.NoError(t, err)
+	assert.True(t, fileExists(filepath.Join(dir, "jid")))
+	assert.Equal(t, "a8f7cd6a1e16e21aa52b61f7047127785867bbb982d441ceef2005355bec94b0", state.Installed[filepath.Join(dir, "jid")].SHA256)
+}
+
+func TestInstallSkipsUpToDatePackages(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	ioutil.WriteFile(filepath.Join(dir, "jid"), []byte("github.com/simeji/jid/cmd/jid"), 0755)
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  sha256: a8f7cd6a1e16e21aa52b61f7047127785867bbb982d441ceef2005355bec94b0
+`
+	r := &fakeRunner{}
+	p := pkg.Packages{
+		Runner: r,
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+
+	assert.NoError(t, err)
+	assert.Empty(t, r.commands)
+}
+
+func TestInstallReinstallsUpToDatePackagesWhenForced(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	ioutil.WriteFile(filepath.Join(dir, "jid"), []byte("github.com/simeji/jid/cmd/jid"), 0755)
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  sha256: a8f7cd6a1e16e21aa52b61f7047127785867bbb982d441ceef2005355bec94b0
+`
+	r := &buildRunner{}
+	p := pkg.Packages{
+		Runner: r,
+		Force:  true,
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
 
-	defer os.RemoveAll(getGolangExamplePackageDir())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, r.gobin)
+}
+
+func TestInstallRefusesBinaryChecksumMismatch(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  sha256: "0000000000000000000000000000000000000000000000000000000000000000"
+`
+	p := pkg.Packages{
+		Runner: &buildRunner{},
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch for")
+	assert.False(t, fileExists(filepath.Join(dir, "jid")))
+}
+
+func TestInstallPrintsPlainLinesWhenNotATerminal(t *testing.T) {
+	data := `
+---
+- url: github.com/golang/example/hello
+  mode: gopath
+`
+	p := pkg.Packages{
+		Runner:    &pkg.RecordRunner{},
+		Observers: []pkg.Observer{pkg.NewProgress(&utils.Logger{Level: utils.LevelInfo}, nil)},
+	}
+	p.UnmarshalYAML([]byte(data))
+	got := capturer.CaptureStdout(func() {
+		err := p.Install(context.Background())
+		assert.NoError(t, err)
+	})
+	want := `^Installing: github.com/golang/example/hello
+Installed: github.com/golang/example/hello in [0-9.]+s
+1 installed, 0 up to date in [0-9.]+s
+$`
+
+	assert.Regexp(t, want, got)
 }
 
 func TestInstallReturnsErrorWhenRunCmdErrors(t *testing.T) {
 	data := `
 ---
 - url: invalid.
+- url: github.com/golang/example/hello
 `
+	r := &fakeRunner{err: errors.New("exit status 1")}
 	p := pkg.Packages{
-		Debug: true,
+		Logger: &utils.Logger{Level: utils.LevelTrace},
+		Runner: r,
 	}
 	p.UnmarshalYAML([]byte(data))
 
-	err := p.Install()
+	err := p.Install(context.Background())
 	assert.Error(t, err)
+	assert.Len(t, r.commands, 1)
+}
+
+func TestApplyReturnsResults(t *testing.T) {
+	data := `
+---
+- url: github.com/golang/example/hello
+- url: invalid.
+- url: github.com/simeji/jid/cmd/jid
+`
+	defer setenv("GOBIN", "/gobin")()
+	r := &fakeRunner{errs: []error{nil, errors.New("exit status 1")}}
+	p := pkg.Packages{
+		Logger: &utils.Logger{Level: utils.LevelError, Out: ioutil.Discard, Err: ioutil.Discard},
+		Runner: &versionRunner{Runner: r, out: goVersionOut},
+	}
+	p.UnmarshalYAML([]byte(data))
+
+	results, err := p.Apply(context.Background())
+	assert.Error(t, err)
+	assert.Len(t, results, 3)
+
+	assert.Equal(t, "github.com/golang/example/hello", results[0].Package)
+	assert.Equal(t, "/gobin/hello", results[0].Binary)
+	assert.Equal(t, pkg.StateDone, results[0].State)
+	assert.NoError(t, results[0].Err)
+
+	assert.Equal(t, pkg.StateFailed, results[1].State)
+	assert.Equal(t, err, results[1].Err)
+
+	assert.Equal(t, pkg.StateQueued, results[2].State)
+	assert.Zero(t, results[2].Duration)
+}
+
+func TestInstallRunsHooks(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  pre_install:
+    - test -d "$GOFILE_BIN_DIR"
+  post_install:
+    - $GOFILE_BINARY --init
+`
+	defer setenv("GOBIN", "/gobin")()
+	r := &fakeRunner{}
+	p := pkg.Packages{
+		Logger: &utils.Logger{Level: utils.LevelError},
+		Runner: r,
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, r.commands, 3)
+	assert.Equal(t, []string{"-c", `test -d "$GOFILE_BIN_DIR"`}, r.commands[0].Args)
+	assert.Equal(t, "go", r.commands[1].Name)
+	assert.Equal(t, []string{"-c", "$GOFILE_BINARY --init"}, r.commands[2].Args)
+	assert.Contains(t, r.commands[2].Env, "GOFILE_BINARY=/gobin/jid")
+}
+
+func TestInstallReturnsErrorWhenHookFails(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  pre_install:
+    - exit 1
+`
+	r := &fakeRunner{err: errors.New("exit status 1")}
+	p := pkg.Packages{
+		Logger: &utils.Logger{Level: utils.LevelError},
+		Runner: r,
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+
+	assert.EqualError(t, err, "installing 'github.com/simeji/jid/cmd/jid' failed: pre-install hook 'exit 1' failed: exit status 1")
+	assert.Len(t, r.commands, 1)
+}
+
+func TestInstallHookErrorWrapsCommandError(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  post_install:
+    - $GOFILE_BINARY --init
+`
+	r := &fakeRunner{errs: []error{nil, &pkg.CommandError{Err: errors.New("exit status 1"), Stderr: "i/o timeout"}}}
+	var events []*pkg.Event
+	p := pkg.Packages{
+		Logger:    &utils.Logger{Level: utils.LevelError},
+		Runner:    r,
+		Observers: []pkg.Observer{recordEvents(&events)},
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+
+	var installErr *pkg.InstallError
+	assert.True(t, errors.As(err, &installErr))
+	assert.True(t, installErr.Transient())
+	assert.Equal(t, "i/o timeout", events[len(events)-1].Stderr)
+}
+
+func TestInstallReturnsContextErrorWhenHookCancelled(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  pre_install:
+    - sleep 60
+`
+	r := &blockingRunner{}
+	p := pkg.Packages{
+		Logger: &utils.Logger{Level: utils.LevelError},
+		Runner: r,
+	}
+	p.UnmarshalYAML([]byte(data))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	err := p.Install(ctx)
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, r.calls)
+}
+
+func TestInstallContinuesOnError(t *testing.T) {
+	data := `
+---
+- url: invalid.
+- url: github.com/golang/example/hello
+`
+	r := &fakeRunner{errs: []error{errors.New("exit status 1")}}
+	p := pkg.Packages{
+		Logger:          &utils.Logger{Level: utils.LevelError},
+		Runner:          &versionRunner{Runner: r, out: goVersionOut},
+		ContinueOnError: true,
+	}
+	p.UnmarshalYAML([]byte(data))
+
+	results, err := p.Apply(context.Background())
+	assert.Error(t, err)
+	assert.Len(t, r.commands, 2)
+	assert.Equal(t, pkg.StateFailed, results[0].State)
+	assert.Equal(t, pkg.StateDone, results[1].State)
+}
+
+func TestInstallReturnsErrorWhenPackageTimesOut(t *testing.T) {
+	data := `
+---
+- url: github.com/golang/example/hello
+  timeout: 10ms
+`
+	p := pkg.Packages{
+		Timeout: time.Hour,
+		Runner:  &versionRunner{Runner: &blockingRunner{}, out: goVersionOut},
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+	want := "installing 'github.com/golang/example/hello' failed: timed out after 10ms"
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestInstallReturnsErrorWhenTimeoutExceeded(t *testing.T) {
+	data := `
+---
+- url: github.com/golang/example/hello
+`
+	p := pkg.Packages{
+		Timeout: 10 * time.Millisecond,
+		Runner:  &versionRunner{Runner: &blockingRunner{}, out: goVersionOut},
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+	want := "installing 'github.com/golang/example/hello' failed: timed out after 10ms"
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestInstallRetriesTimeouts(t *testing.T) {
+	data := `
+---
+- url: github.com/golang/example/hello
+  timeout: 10ms
+  retries: 1
+`
+	r := &blockingRunner{}
+	p := pkg.Packages{
+		Logger:  &utils.Logger{Level: utils.LevelError},
+		Backoff: time.Millisecond,
+		Runner:  &versionRunner{Runner: r, out: goVersionOut},
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+	want := "installing 'github.com/golang/example/hello' failed after 2 attempts: timed out after 10ms"
+
+	assert.Equal(t, want, err.Error())
+	assert.Equal(t, 2, r.calls)
+}
+
+func TestInstallReturnsContextErrorWhenCancelledAfterRetry(t *testing.T) {
+	data := `
+---
+- url: github.com/golang/example/hello
+  timeout: 20ms
+  retries: 5
+`
+	r := &blockingRunner{}
+	p := pkg.Packages{
+		Logger:  &utils.Logger{Level: utils.LevelError},
+		Backoff: time.Millisecond,
+		Runner:  &versionRunner{Runner: r, out: goVersionOut},
+	}
+	p.UnmarshalYAML([]byte(data))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(30*time.Millisecond, cancel)
+	err := p.Install(ctx)
+
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, r.calls >= 2)
+}
+
+func TestInstallReturnsErrorWhenContextCancelled(t *testing.T) {
+	data := `
+---
+- url: github.com/golang/example/hello
+- url: github.com/simeji/jid/cmd/jid
+`
+	r := &blockingRunner{}
+	p := pkg.Packages{
+		Runner: &versionRunner{Runner: r, out: goVersionOut},
+	}
+	p.UnmarshalYAML([]byte(data))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	err := p.Install(ctx)
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, r.calls)
+}
+
+func TestInstallRetriesTransientErrors(t *testing.T) {
+	data := `
+---
+- url: github.com/golang/example/hello
+`
+	r := &fakeRunner{errs: []error{
+		&pkg.CommandError{Err: errors.New("exit status 1"), Stderr: "reading https://proxy.golang.org/foo: 502 Bad Gateway"},
+		&pkg.CommandError{Err: errors.New("exit status 1"), Stderr: "i/o timeout"},
+	}}
+	p := pkg.Packages{
+		Retries: 2,
+		Backoff: time.Millisecond,
+		Runner:  &versionRunner{Runner: r, out: goVersionOut},
+	}
+	p.UnmarshalYAML([]byte(data))
+	got := capturer.CaptureStdout(func() {
+		err := p.Install(context.Background())
+		assert.NoError(t, err)
+	})
+
+	assert.Len(t, r.commands, 3)
+	assert.Contains(t, got, "(attempt 2 of 3) in 1ms")
+	assert.Contains(t, got, "(attempt 3 of 3) in 2ms")
+}
+
+func TestInstallReturnsErrorWhenRetriesExhausted(t *testing.T) {
+	data := `
+---
+- url: github.com/golang/example/hello
+  retries: 1
+`
+	r := &fakeRunner{err: &pkg.CommandError{Err: errors.New("exit status 1"), Stderr: "reading https://proxy.golang.org/foo: 502 Bad Gateway"}}
+	p := pkg.Packages{
+		Retries: 5,
+		Backoff: time.Millisecond,
+		Runner:  &versionRunner{Runner: r, out: goVersionOut},
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+	want := "installing 'github.com/golang/example/hello' failed after 2 attempts: exit status 1: reading https://proxy.golang.org/foo: 502 Bad Gateway"
+
+	assert.Equal(t, want, err.Error())
+	assert.Len(t, r.commands, 2)
+}
+
+func TestInstallDoesNotRetryPermanentErrors(t *testing.T) {
+	data := `
+---
+- url: invalid.
+`
+	r := &fakeRunner{err: &pkg.CommandError{Err: errors.New("exit status 1"), Stderr: "cannot find package"}}
+	p := pkg.Packages{
+		Retries: 5,
+		Backoff: time.Millisecond,
+		Runner:  &versionRunner{Runner: r, out: goVersionOut},
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+
+	assert.Equal(t, "installing 'invalid.' failed: exit status 1: cannot find package", err.Error())
+	assert.Len(t, r.commands, 1)
 }
 
 func TestRunCommand(t *testing.T) {
 	got := capturer.CaptureStdout(func() {
-		err := p.RunCmd("ls")
+		err := p.RunCmd(context.Background(), "ls")
 		assert.NoError(t, err)
 	})
 
@@ -146,23 +670,23 @@ func TestRunCommand(t *testing.T) {
 
 func TestRunCommandPrintsStreamingStdout(t *testing.T) {
 	p := pkg.Packages{
-		Debug: true,
+		Logger: &utils.Logger{Level: utils.LevelTrace},
 	}
 	got := capturer.CaptureStdout(func() {
-		err := p.RunCmd("echo", "-n", "foo")
+		err := p.RunCmd(context.Background(), "echo", "-n", "foo")
 		assert.NoError(t, err)
 	})
-	want := "COMMAND: \x1b[30;41mecho -n foo\x1b[0m\nfoo"
+	want := "COMMAND: echo -n foo\nfoo"
 
 	assert.Equal(t, want, got)
 }
 
 func TestRunCommandPrintsStreamingStderr(t *testing.T) {
 	p := pkg.Packages{
-		Debug: true,
+		Logger: &utils.Logger{Level: utils.LevelTrace},
 	}
 	got := capturer.CaptureStderr(func() {
-		err := p.RunCmd("cat", "foo")
+		err := p.RunCmd(context.Background(), "cat", "foo")
 		assert.Error(t, err)
 	})
 	want := "cat: foo: No such file or directory\n"
@@ -171,12 +695,127 @@ func TestRunCommandPrintsStreamingStderr(t *testing.T) {
 }
 
 func TestRunCommandReturnsError(t *testing.T) {
-	err := p.RunCmd("false")
+	err := p.RunCmd(context.Background(), "false")
 
 	assert.Error(t, err)
 }
 
-func getGolangExamplePackageDir() string {
-	return filepath.Join(os.Getenv("GOPATH"), "src", "github.com", "golang", "example")
+// fakeRunner records the commands it is asked to run, and returns the next
+// error of `errs` from each of them, falling back to `err` once exhausted.
+type fakeRunner struct {
+	commands []*pkg.Command
+	errs     []error
+	err      error
+}
+
+func (r *fakeRunner) Run(ctx context.Context, cmd *pkg.Command) error {
+	r.commands = append(r.commands, cmd)
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+
+		return err
+	}
+
+	return r.err
+}
+
+// goVersionOut is the output of `go version` answered by `versionRunner`.
+const goVersionOut = "go version go1.21.0 linux/amd64"
+
+// versionRunner answers `go version` with `out`, and delegates the other
+// commands to the embedded runner.
+type versionRunner struct {
+	pkg.Runner
+	out      string
+	versions int
+}
+
+func (r *versionRunner) Run(ctx context.Context, cmd *pkg.Command) error {
+	if len(cmd.Args) == 1 && cmd.Args[0] == "version" {
+		r.versions++
+		io.WriteString(cmd.Stdout, r.out)
+
+		return nil
+	}
+
+	return r.Runner.Run(ctx, cmd)
+}
+
+// modfileRunner records the commands, along with the go.mod the last one was
+// run with and the permissions of its directory.
+type modfileRunner struct {
+	pkg.RecordRunner
+	modfile string
+	perm    os.FileMode
+}
+
+func (r *modfileRunner) Run(ctx context.Context, cmd *pkg.Command) error {
+	for _, arg := range cmd.Args {
+		if strings.HasPrefix(arg, "-modfile=") {
+			modfile := strings.TrimPrefix(arg, "-modfile=")
+			data, _ := ioutil.ReadFile(modfile)
+			r.modfile = string(data)
+			if fi, err := os.Stat(filepath.Dir(modfile)); err == nil {
+				r.perm = fi.Mode().Perm()
+			}
+		}
+	}
+
+	return r.RecordRunner.Run(ctx, cmd)
+}
+
+// setenv sets the environment variable named by key, and returns a function
+// restoring its previous value.
+func setenv(key string, value string) func() {
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+// blockingRunner blocks each command until the provided context is done.
+type blockingRunner struct {
+	calls int
+}
+
+func (r *blockingRunner) Run(ctx context.Context, cmd *pkg.Command) error {
+	r.calls++
+	<-ctx.Done()
+
+	return errors.New("signal: terminated")
+}
+
+// fileExists returns true when the named file exists.
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+
+	return err == nil
+}
+
+// buildRunner writes a fake binary named after the last argument into the
+// GOBIN of each command.
+type buildRunner struct {
+	gobin string
+}
+
+func (r *buildRunner) Run(ctx context.Context, cmd *pkg.Command) error {
+	for _, e := range cmd.Env {
+		if strings.HasPrefix(e, "GOBIN=") {
+			r.gobin = strings.TrimPrefix(e, "GOBIN=")
+		}
+	}
+	url := cmd.Args[len(cmd.Args)-1]
+	binary := filepath.Join(r.gobin, path.Base(url))
+	os.MkdirAll(r.gobin, 0755)
+	// Like the toolchain, replace rather than write through a linked binary.
+	os.Remove(binary)
 
+	return ioutil.WriteFile(binary, []byte(url), 0755)
 }
@@ -47,16 +47,57 @@ func TestValidateSchemaHasErrorReturnsError(t *testing.T) {
 func TestValidateWithoutRootArrayReturnsError(t *testing.T) {
 	data := `
 ---
+foo
+`
+	jsonData, _ := yaml.YAMLToJSON([]byte(data))
+	err := p.validate([]byte(jsonData))
+	want := &ValidationError{Errors: []string{"(root): Invalid type. Expected: array, given: string"}}
+
+	assert.Error(t, err)
+	assert.Equal(t, want, err)
+}
+
+func TestValidateWithoutPackagesKeyReturnsError(t *testing.T) {
+	data := `
+---
 foo:
 `
 	jsonData, _ := yaml.YAMLToJSON([]byte(data))
 	err := p.validate([]byte(jsonData))
-	want := errors.New("(root): Invalid type. Expected: array, given: object")
+	want := &ValidationError{Errors: []string{"packages: packages is required", "foo: Additional property foo is not allowed"}}
 
 	assert.Error(t, err)
 	assert.Equal(t, want, err)
 }
 
+func TestValidateManifestWithInvalidPackageReturnsError(t *testing.T) {
+	data := `
+---
+go: go1.20
+packages:
+  - url:
+`
+	jsonData, _ := yaml.YAMLToJSON([]byte(data))
+	err := p.validate([]byte(jsonData))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "packages.0.url: Invalid type. Expected: string, given: null")
+}
+
+func TestValidateManifest(t *testing.T) {
+	data := `
+---
+go: go1.20
+packages:
+  - url: https://example.com/user/repo.git
+    go: ~/sdk/go1.21.0
+`
+	jsonData, _ := yaml.YAMLToJSON([]byte(data))
+	err := p.validate([]byte(jsonData))
+
+	assert.NoError(t, err)
+}
+
 func TestValidateWithoutStringReturnsError(t *testing.T) {
 	data := `
 ---
@@ -82,16 +123,128 @@ func TestValidateWithoutURLKeyReturnsError(t *testing.T) {
 `
 	jsonData, _ := yaml.YAMLToJSON([]byte(data))
 	err := p.validate([]byte(jsonData))
-	want := errors.New("url: url is required")
+	want := &ValidationError{Errors: []string{"0: Must validate one and only one schema (oneOf)", "url: url is required"}}
 
 	assert.Error(t, err)
 	assert.Equal(t, want, err)
 }
 
+func TestValidateWithURLAndPathReturnsError(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  path: ../jid
+`
+	jsonData, _ := yaml.YAMLToJSON([]byte(data))
+	err := p.validate([]byte(jsonData))
+	want := &ValidationError{Errors: []string{"0: Must validate one and only one schema (oneOf)"}}
+
+	assert.Error(t, err)
+	assert.Equal(t, want, err)
+}
+
+func TestValidateWithReplaceWithoutPathReturnsError(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  replace:
+    github.com/nsf/termbox-go: ../termbox-go
+`
+	jsonData, _ := yaml.YAMLToJSON([]byte(data))
+	err := p.validate([]byte(jsonData))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "0: Has a dependency on path")
+}
+
+func TestValidateWithReleaseWithoutBinaryReturnsError(t *testing.T) {
+	data := `
+---
+- release:
+    url: https://example.com/jid.zip
+    checksums: https://example.com/checksums.txt
+`
+	jsonData, _ := yaml.YAMLToJSON([]byte(data))
+	err := p.validate([]byte(jsonData))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "binary: binary is required")
+}
+
+func TestValidateWithRelease(t *testing.T) {
+	data := `
+---
+- release:
+    url: https://example.com/v{{.Version}}/jid_{{.OS}}_{{.Arch}}.zip
+    checksums: https://example.com/v{{.Version}}/checksums.txt
+    binary: jid
+  version: 0.7.6
+`
+	jsonData, _ := yaml.YAMLToJSON([]byte(data))
+	err := p.validate([]byte(jsonData))
+
+	assert.NoError(t, err)
+}
+
+func TestValidateWithPath(t *testing.T) {
+	data := `
+---
+- path: ../jid
+  replace:
+    github.com/nsf/termbox-go: ../termbox-go
+`
+	jsonData, _ := yaml.YAMLToJSON([]byte(data))
+	err := p.validate([]byte(jsonData))
+
+	assert.NoError(t, err)
+}
+
+func TestValidateWithInvalidTimeoutReturnsError(t *testing.T) {
+	data := `
+---
+- url: https://example.com/user/repo.git
+  timeout: soon
+`
+	jsonData, _ := yaml.YAMLToJSON([]byte(data))
+	err := p.validate([]byte(jsonData))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "0.timeout: Does not match pattern")
+}
+
+func TestValidateWithInvalidModeReturnsError(t *testing.T) {
+	data := `
+---
+- url: https://example.com/user/repo.git
+  mode: vendor
+`
+	jsonData, _ := yaml.YAMLToJSON([]byte(data))
+	err := p.validate([]byte(jsonData))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "0.mode: 0.mode must be one of the following")
+}
+
+func TestValidateWithInvalidSHA256ReturnsError(t *testing.T) {
+	data := `
+---
+- url: https://example.com/user/repo.git
+  sha256: abc
+`
+	jsonData, _ := yaml.YAMLToJSON([]byte(data))
+	err := p.validate([]byte(jsonData))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "0.sha256: Does not match pattern")
+}
+
 func TestValidate(t *testing.T) {
 	data := `
 ---
 - url: https://example.com/user/repo.git
+  timeout: 1m30s
+  version: v1.2.3
+  mode: module
 `
 	jsonData, _ := yaml.YAMLToJSON([]byte(data))
 	err := p.validate([]byte(jsonData))
@@ -0,0 +1,411 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/retr0h/gofile/utils"
+)
+
+// majorVersionPattern matches the major version suffix of a module path.
+var majorVersionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// Output formats understood by `PrintPlan`, `PrintInstalled`, `PrintCheck`,
+// and `Install`.
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// Step containing the command which installs a single package, and the
+// directory the resulting binary is installed into.
+type Step struct {
+	URL         string            `json:"url,omitempty"`
+	Path        string            `json:"path,omitempty"`
+	Replace     map[string]string `json:"replace,omitempty"`
+	Version     string            `json:"version,omitempty"`
+	Mode        string            `json:"mode,omitempty"`
+	Go          string            `json:"go,omitempty"` // Go toolchain running the command, `go` on the PATH when empty.
+	Binary      string            `json:"binary,omitempty"`
+	Isolated    bool              `json:"isolated,omitempty"`
+	Release     *Release          `json:"release,omitempty"`
+	SHA256      string            `json:"sha256,omitempty"`      // SHA256 of the release asset, or the built binary.
+	PreInstall  []string          `json:"pre_install,omitempty"` // PreInstall commands run before the command.
+	Command     *Command          `json:"command,omitempty"`
+	PostInstall []string          `json:"post_install,omitempty"` // PostInstall commands run once installed.
+	Completion  string            `json:"completion,omitempty"`   // Completion arguments generating completion scripts.
+	BinDir      string            `json:"bin_dir"`
+	Timeout     time.Duration     `json:"-"`
+	Retries     int               `json:"-"`
+	TempDir     string            `json:"temp_dir,omitempty"` // TempDir created privately for the install, and removed once done.
+	Stage       string            `json:"stage,omitempty"`    // Stage is the throwaway GOPATH within `TempDir` the command installs into.
+	Modfile     string            `json:"-"`                  // Modfile containing `Replace`, written into `TempDir` before install.
+	importPath  string            // Import path of the local checkout, which names its binary.
+}
+
+// Name returns the URL of the step's package, its path when installing from
+// a local checkout, or its download URL when installing a release.
+func (s *Step) Name() string {
+	if s.Path != "" {
+		return s.Path
+	}
+
+	if s.Release != nil {
+		return s.Release.URL
+	}
+
+	return s.URL
+}
+
+// tempDirPath returns a unique path within the system's temporary directory.
+// It is created when installing rather than planning, and creating it fails
+// when it already exists, so other users cannot prepare its contents.
+func tempDirPath() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(os.TempDir(), "gofile-"+hex.EncodeToString(b)), nil
+}
+
+// Plan resolves the command `Install` executes for each package without
+// installing anything.  Only `go version` is run through the `Runner`, when
+// the install mode of a toolchain needs detecting.
+func (p *Packages) Plan() ([]*Step, error) {
+	return p.plan(context.Background())
+}
+
+// plan resolves the steps of `Plan`, querying toolchains with the provided
+// context.
+func (p *Packages) plan(ctx context.Context) ([]*Step, error) {
+	binDir, err := binDir()
+	if err != nil {
+		return nil, err
+	}
+	if p.toolDir != "" {
+		binDir = p.toolDir
+	}
+
+	// Modes detected for each toolchain, detected only when a package needs it.
+	modes := make(map[string]string)
+
+	var steps []*Step
+	for _, pkg := range p.Packages {
+		if pkg.Release != nil {
+			step, err := p.planPackage(pkg, binDir, "")
+			if err != nil {
+				return nil, err
+			}
+
+			steps = append(steps, step)
+			continue
+		}
+
+		toolchain := p.Go
+		if pkg.Go != "" {
+			toolchain = pkg.Go
+		}
+
+		goBin, err := resolveToolchain(toolchain)
+		if err != nil {
+			return nil, err
+		}
+
+		mode, ok := modes[goBin]
+		if !ok && pkg.Path == "" && pkg.Mode == "" {
+			if mode, err = p.detectMode(ctx, goBin); err != nil {
+				return nil, err
+			}
+			modes[goBin] = mode
+		}
+
+		step, err := p.planPackage(pkg, binDir, mode)
+		if err != nil {
+			return nil, err
+		}
+
+		if toolchain != "" {
+			step.Go = goBin
+			step.Command.Name = goBin
+		}
+		step.Binary = filepath.Join(binDir, binaryName(step))
+
+		if step.Isolated || step.SHA256 != "" || p.locks(step) {
+			if err := p.planStaged(ctx, step); err != nil {
+				return nil, err
+			}
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// binaryName returns the name of the binary installed by the provided step,
+// which is the last element of the package's import path, or of a local
+// checkout's import path, skipping major version suffixes, or the name of
+// the binary extracted from a release.
+func binaryName(step *Step) string {
+	if step.Release != nil {
+		return path.Base(step.Release.Binary)
+	}
+
+	importPath := step.URL
+	if step.Path != "" {
+		importPath = step.importPath
+	}
+
+	elems := strings.Split(importPath, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && majorVersionPattern.MatchString(name) {
+		name = elems[len(elems)-2]
+	}
+
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	return name
+}
+
+// planPackage resolves the step installing the provided package into binDir,
+// with the provided mode unless the package forces one.
+func (p *Packages) planPackage(pkg Package, binDir string, mode string) (*Step, error) {
+	step := &Step{
+		URL:        pkg.URL,
+		Version:    pkg.Version,
+		BinDir:     binDir,
+		Isolated:   p.Isolated,
+		SHA256:     pkg.SHA256,
+		Completion: pkg.Completion,
+		Timeout:    p.Timeout,
+		Retries:    p.Retries,
+	}
+
+	// Hooks of the gofile wrap the hooks of the package.
+	step.PreInstall = append(append(step.PreInstall, p.PreInstall...), pkg.PreInstall...)
+	step.PostInstall = append(append(step.PostInstall, pkg.PostInstall...), p.PostInstall...)
+
+	if pkg.Timeout != "" {
+		timeout, err := time.ParseDuration(pkg.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		step.Timeout = timeout
+	}
+
+	if pkg.Retries != nil {
+		step.Retries = *pkg.Retries
+	}
+
+	if pkg.Release != nil {
+		return p.planRelease(step, pkg)
+	}
+
+	if pkg.Path != "" {
+		return p.planLocalPackage(step, pkg)
+	}
+
+	step.Mode = mode
+	if pkg.Mode != "" {
+		step.Mode = pkg.Mode
+	}
+
+	if step.Mode == ModeGOPATH {
+		return p.planGOPATHPackage(step, pkg)
+	}
+
+	version := pkg.Version
+	if version == "" {
+		version = "latest"
+	}
+
+	goCmdArgs := []string{"install"}
+	if p.log().Level >= utils.LevelTrace {
+		goCmdArgs = append(goCmdArgs, "-v")
+	}
+	goCmdArgs = append(goCmdArgs, pkg.URL+"@"+version)
+
+	env := []string{"GOBIN=" + binDir}
+	if p.Offline {
+		proxy, err := p.offlineProxy()
+		if err != nil {
+			return nil, err
+		}
+		// The checksum database is online, the lock verifies modules instead.
+		env = append(env, "GOPROXY="+proxy, "GOSUMDB=off")
+	}
+
+	step.Command = &Command{
+		Name: "go",
+		Args: goCmdArgs,
+		Env:  env,
+	}
+
+	return step, nil
+}
+
+// offlineProxy returns the GOPROXY serving modules from `Packages.ProxyDir`,
+// or disabling the proxy to use only the module cache when unset.
+func (p *Packages) offlineProxy() (string, error) {
+	if p.ProxyDir == "" {
+		return "off", nil
+	}
+
+	dir, err := filepath.Abs(p.ProxyDir)
+	if err != nil {
+		return "", err
+	}
+
+	return "file://" + filepath.ToSlash(dir), nil
+}
+
+// planGOPATHPackage completes the provided step with the GOPATH-mode `go get`
+// command, which cannot install a specific version.
+func (p *Packages) planGOPATHPackage(step *Step, pkg Package) (*Step, error) {
+	if pkg.Version != "" {
+		return nil, &ValidationError{Errors: []string{
+			fmt.Sprintf("'%s' sets a version, which requires module mode", pkg.URL),
+		}}
+	}
+
+	goCmdArgs := []string{"get"}
+	if p.log().Level >= utils.LevelTrace {
+		goCmdArgs = append(goCmdArgs, "-v")
+	}
+	goCmdArgs = append(goCmdArgs, pkg.URL)
+
+	step.Command = &Command{
+		Name: "go",
+		Args: goCmdArgs,
+		Env:  []string{"GOBIN=" + step.BinDir, "GO111MODULE=off"},
+	}
+
+	return step, nil
+}
+
+// PrintPlan writes the provided steps to w in the requested format.
+func PrintPlan(w io.Writer, steps []*Step, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(steps)
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, step := range steps {
+			if err := enc.Encode(step); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case FormatText:
+		for _, step := range steps {
+			fmt.Fprintf(w, "Package: %s\n", step.Name())
+			if step.Mode != "" {
+				fmt.Fprintf(w, "  Mode: %s\n", step.Mode)
+			}
+			if step.Go != "" {
+				fmt.Fprintf(w, "  Go: %s\n", step.Go)
+			}
+			if step.Isolated {
+				fmt.Fprintf(w, "  Isolated: true\n")
+			}
+			if step.SHA256 != "" {
+				fmt.Fprintf(w, "  SHA256: %s\n", step.SHA256)
+			}
+			for _, module := range sortedKeys(step.Replace) {
+				fmt.Fprintf(w, "  Replace: %s => %s\n", module, step.Replace[module])
+			}
+			if step.Release != nil {
+				fmt.Fprintf(w, "  Download: %s\n", step.Release.URL)
+				if step.Release.Checksums != "" {
+					fmt.Fprintf(w, "  Checksums: %s\n", step.Release.Checksums)
+				}
+				fmt.Fprintf(w, "  Binary: %s\n", step.Release.Binary)
+			}
+			for _, hook := range step.PreInstall {
+				fmt.Fprintf(w, "  Pre-install: %s\n", hook)
+			}
+			if step.Stage != "" {
+				fmt.Fprintf(w, "  Stage: %s\n", step.Stage)
+			}
+			if step.Command != nil {
+				fmt.Fprintf(w, "  Command: %s\n", step.Command)
+				if len(step.Command.Env) > 0 {
+					fmt.Fprintf(w, "  Env: %s\n", strings.Join(step.Command.Env, " "))
+				}
+				if step.Command.Dir != "" {
+					fmt.Fprintf(w, "  Dir: %s\n", step.Command.Dir)
+				}
+			}
+			if step.Completion != "" {
+				fmt.Fprintf(w, "  Completion: %s\n", step.Completion)
+			}
+			for _, hook := range step.PostInstall {
+				fmt.Fprintf(w, "  Post-install: %s\n", hook)
+			}
+			fmt.Fprintf(w, "  Target: %s\n", step.BinDir)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown format '%s'", format)
+	}
+}
+
+// binDir returns the directory the go toolchain installs binaries into.  Which is
+// $GOBIN when set, otherwise the bin directory of the first $GOPATH entry,
+// falling back to the default GOPATH of $HOME/go.
+func binDir() (string, error) {
+	if gobin := os.Getenv("GOBIN"); gobin != "" {
+		return gobin, nil
+	}
+
+	if gopaths := filepath.SplitList(os.Getenv("GOPATH")); len(gopaths) > 0 {
+		return filepath.Join(gopaths[0], "bin"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, "go", "bin"), nil
+}
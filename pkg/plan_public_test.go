@@ -0,0 +1,358 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+`
+	defer setenv("GOBIN", "/gobin")()
+	defer setenv("GO111MODULE", "on")()
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Plan()
+	want := []*pkg.Step{
+		{
+			URL:    "github.com/simeji/jid/cmd/jid",
+			Mode:   pkg.ModeModule,
+			Binary: "/gobin/jid",
+			Command: &pkg.Command{
+				Name: "go",
+				Args: []string{"install", "github.com/simeji/jid/cmd/jid@latest"},
+				Env:  []string{"GOBIN=/gobin"},
+			},
+			BinDir: "/gobin",
+		},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestPlanWrapsPackageHooksWithGofileHooks(t *testing.T) {
+	data := `
+---
+pre_install:
+  - echo global pre
+post_install:
+  - echo global post
+packages:
+  - url: github.com/simeji/jid/cmd/jid
+    pre_install:
+      - echo pre
+    post_install:
+      - jid --init
+`
+	defer setenv("GOBIN", "/gobin")()
+	defer setenv("GO111MODULE", "on")()
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Plan()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"echo global pre", "echo pre"}, got[0].PreInstall)
+	assert.Equal(t, []string{"jid --init", "echo global post"}, got[0].PostInstall)
+}
+
+func TestPlanUsesGOPATHWhenGOBINUnset(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+`
+	defer setenv("GOBIN", "")()
+	defer setenv("GOPATH", "/gopath:/other")()
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Plan()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/gopath/bin", got[0].BinDir)
+}
+
+func TestPlanGOPATHModeWhenModulesDisabled(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+`
+	defer setenv("GOBIN", "/gobin")()
+	defer setenv("GO111MODULE", "off")()
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Plan()
+	want := &pkg.Command{
+		Name: "go",
+		Args: []string{"get", "github.com/simeji/jid/cmd/jid"},
+		Env:  []string{"GOBIN=/gobin", "GO111MODULE=off"},
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, pkg.ModeGOPATH, got[0].Mode)
+	assert.Equal(t, want, got[0].Command)
+}
+
+func TestPlanReturnsErrorWithVersionInGOPATHMode(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  version: v0.7.6
+  mode: gopath
+`
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	_, err := p.Plan()
+	want := "'github.com/simeji/jid/cmd/jid' sets a version, which requires module mode"
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestPlanWithToolchain(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "go1.20", "bin"), 0755)
+	goBin := filepath.Join(dir, "go1.20", "bin", "go")
+	ioutil.WriteFile(goBin, []byte("#!/bin/sh\necho go version go1.20.3 linux/amd64\n"), 0755)
+	data := `
+---
+go: ` + filepath.Join(dir, "go1.20") + `
+packages:
+  - url: github.com/simeji/jid/cmd/jid
+  - url: github.com/arsham/figurine
+    go: go
+`
+	defer setenv("GO111MODULE", "on")()
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Plan()
+
+	assert.NoError(t, err)
+	assert.Equal(t, goBin, got[0].Go)
+	assert.Equal(t, goBin, got[0].Command.Name)
+	assert.Equal(t, pkg.ModeModule, got[0].Mode)
+}
+
+func TestPlanReturnsErrorWithMissingToolchain(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  go: /missing/go
+`
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	_, err := p.Plan()
+	want := "toolchain '/missing/go' not found"
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestPlanLocalPath(t *testing.T) {
+	data := `
+---
+- path: /src/jid
+  replace:
+    github.com/nsf/termbox-go: /src/termbox-go
+`
+	defer setenv("GOBIN", "/gobin")()
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Plan()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/src/jid", got[0].Name())
+	assert.Equal(t, map[string]string{"github.com/nsf/termbox-go": "/src/termbox-go"}, got[0].Replace)
+	assert.Equal(t, &pkg.Command{
+		Name: "go",
+		Args: []string{"install", "-modfile=" + got[0].Modfile, "."},
+		Env:  []string{"GOBIN=/gobin"},
+		Dir:  "/src/jid",
+	}, got[0].Command)
+}
+
+func TestPlanLocalPathNamesBinaryAfterModule(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "checkout-fork"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "checkout-fork", "go.mod"), []byte("module example.com/hello\n"), 0644)
+	defer setenv("GOBIN", "/gobin")()
+	p := pkg.Packages{
+		Packages: []pkg.Package{{Path: filepath.Join(dir, "checkout-fork")}},
+	}
+	got, err := p.Plan()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/gobin/hello", got[0].Binary)
+}
+
+func TestPlanLocalPathRelativeToGofile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "gofile.yml")
+	ioutil.WriteFile(filename, []byte("- path: ./jid\n"), 0644)
+
+	p := pkg.Packages{}
+	p.UnmarshalYAMLFile(filename)
+	got, err := p.Plan()
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "jid"), got[0].Command.Dir)
+	assert.Equal(t, []string{"install", "."}, got[0].Command.Args)
+}
+
+func TestPlanStagesVerifiedPackages(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+  mode: gopath
+  sha256: a8f7cd6a1e16e21aa52b61f7047127785867bbb982d441ceef2005355bec94b0
+`
+	defer setenv("GOBIN", "/gobin")()
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Plan()
+
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(got[0].TempDir, "gopath"), got[0].Stage)
+	assert.Equal(t, []string{"GO111MODULE=off", "GOBIN=" + filepath.Join(got[0].Stage, "bin")}, got[0].Command.Env)
+	assert.Equal(t, "/gobin/jid", got[0].Binary)
+	assert.False(t, fileExists(got[0].TempDir))
+
+	var buf bytes.Buffer
+	pkg.PrintPlan(&buf, got, pkg.FormatText)
+
+	assert.Contains(t, buf.String(), "  Stage: "+got[0].Stage+"\n")
+	assert.Contains(t, buf.String(), "  Env: GO111MODULE=off GOBIN="+filepath.Join(got[0].Stage, "bin")+"\n")
+}
+
+func TestPlanDoesNotRunCommands(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+`
+	defer setenv("GO111MODULE", "on")()
+	r := &pkg.RecordRunner{}
+	v := &versionRunner{Runner: r, out: goVersionOut}
+	p := pkg.Packages{
+		Runner: v,
+	}
+	p.UnmarshalYAML([]byte(data))
+	_, err := p.Plan()
+
+	assert.NoError(t, err)
+	assert.Empty(t, r.Commands)
+	assert.Equal(t, 1, v.versions)
+}
+
+func TestPlanDetectsModeThroughRunner(t *testing.T) {
+	data := `
+---
+- url: github.com/simeji/jid/cmd/jid
+`
+	defer setenv("GO111MODULE", "on")()
+	p := pkg.Packages{
+		Runner: &versionRunner{Runner: &pkg.RecordRunner{}, out: "go version go1.15.2 linux/amd64"},
+	}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Plan()
+
+	assert.NoError(t, err)
+	assert.Equal(t, pkg.ModeGOPATH, got[0].Mode)
+}
+
+func TestPrintPlanText(t *testing.T) {
+	var buf bytes.Buffer
+	err := pkg.PrintPlan(&buf, getSteps(), pkg.FormatText)
+	want := `Package: github.com/simeji/jid/cmd/jid
+  Command: go get github.com/simeji/jid/cmd/jid
+  Env: GOBIN=/gobin
+  Target: /gobin
+`
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, buf.String())
+}
+
+func TestPrintPlanJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := pkg.PrintPlan(&buf, getSteps(), pkg.FormatJSON)
+	want := `[
+  {
+    "url": "github.com/simeji/jid/cmd/jid",
+    "command": {
+      "name": "go",
+      "args": [
+        "get",
+        "github.com/simeji/jid/cmd/jid"
+      ],
+      "env": [
+        "GOBIN=/gobin"
+      ]
+    },
+    "bin_dir": "/gobin"
+  }
+]
+`
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, buf.String())
+}
+
+func TestPrintPlanNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := pkg.PrintPlan(&buf, getSteps(), pkg.FormatNDJSON)
+	want := `{"url":"github.com/simeji/jid/cmd/jid","command":{"name":"go","args":["get","github.com/simeji/jid/cmd/jid"],"env":["GOBIN=/gobin"]},"bin_dir":"/gobin"}
+`
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, buf.String())
+}
+
+func TestPrintPlanReturnsErrorWithUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := pkg.PrintPlan(&buf, getSteps(), "yaml")
+	want := "unknown format 'yaml'"
+
+	assert.Equal(t, want, err.Error())
+}
+
+func getSteps() []*pkg.Step {
+	return []*pkg.Step{
+		{
+			URL: "github.com/simeji/jid/cmd/jid",
+			Command: &pkg.Command{
+				Name: "go",
+				Args: []string{"get", "github.com/simeji/jid/cmd/jid"},
+				Env:  []string{"GOBIN=/gobin"},
+			},
+			BinDir: "/gobin",
+		},
+	}
+}
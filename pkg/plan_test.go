@@ -0,0 +1,42 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinaryName(t *testing.T) {
+	tests := map[string]*Step{
+		"jid":       {URL: "github.com/simeji/jid/cmd/jid"},
+		"golint":    {URL: "golang.org/x/lint/golint"},
+		"gopls":     {URL: "golang.org/x/tools/gopls/v2"},
+		"v2":        {URL: "v2"},
+		"termbox":   {Path: "/src/termbox-fork", importPath: "github.com/nsf/termbox"},
+		"hello":     {Path: "/src/hello/v2", importPath: "example.com/hello/v2"},
+		"goimports": {URL: "golang.org/x/tools/cmd/goimports"},
+	}
+	for want, step := range tests {
+		assert.Equal(t, want, binaryName(step))
+	}
+}
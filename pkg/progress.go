@@ -0,0 +1,285 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/retr0h/gofile/utils"
+)
+
+// States of a package shown by the progress display.
+const (
+	StateQueued      = "queued"
+	StateDownloading = "downloading"
+	StateBuilding    = "building"
+	StateDone        = "done"
+	StateUpToDate    = "up to date"
+	StateFailed      = "failed"
+)
+
+// progressFrames animate the packages in progress.
+var progressFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Progress observes installs, displaying the state of each package.  On a
+// terminal it redraws one line per package with its elapsed time, otherwise
+// it logs a plain line whenever a package changes state.  Both finish with a
+// summary.
+type Progress struct {
+	log       *utils.Logger
+	term      io.Writer // Terminal redrawn in place, plain lines are logged when nil.
+	mu        sync.Mutex
+	tasks     []*task
+	byStep    map[*Step]*task
+	drawn     int
+	frame     int
+	start     time.Time
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	animating bool // Animating while redrawing the terminal.
+}
+
+// task containing the state of a package shown by the progress display.
+type task struct {
+	name    string
+	state   string
+	start   time.Time
+	elapsed time.Duration
+}
+
+// NewProgress returns an observer displaying the progress of installs,
+// which logs plain lines to log unless provided a terminal to redraw.  Log
+// messages written to the terminal while installing go through the
+// progress, e.g. with `log.WithOut(progress)`, to be written above it.
+func NewProgress(log *utils.Logger, term io.Writer) *Progress {
+	return &Progress{
+		log:  log,
+		term: term,
+	}
+}
+
+// OnPlan queues the planned packages, replacing those of a previous
+// install, and starts redrawing the terminal.
+func (pr *Progress) OnPlan(steps []*Step) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	pr.start = time.Now()
+	pr.tasks = nil
+	pr.byStep = make(map[*Step]*task)
+	pr.drawn = 0
+	pr.stop = make(chan struct{})
+	for _, step := range steps {
+		t := &task{name: step.Name(), state: StateQueued}
+		pr.tasks = append(pr.tasks, t)
+		pr.byStep[step] = t
+	}
+
+	if pr.term != nil {
+		pr.animating = true
+		pr.wg.Add(1)
+		go pr.animate()
+	}
+}
+
+// animate redraws the terminal until stopped.
+func (pr *Progress) animate() {
+	defer pr.wg.Done()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pr.stop:
+			return
+		case <-ticker.C:
+			pr.mu.Lock()
+			pr.frame++
+			pr.redraw()
+			pr.mu.Unlock()
+		}
+	}
+}
+
+// set changes the state of the provided step's package.
+func (pr *Progress) set(step *Step, state string) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	t := pr.byStep[step]
+	switch state {
+	case StateDownloading, StateBuilding:
+		t.start = time.Now()
+	case StateDone, StateFailed:
+		t.elapsed = time.Since(t.start)
+	}
+	t.state = state
+
+	if pr.term != nil {
+		pr.redraw()
+		return
+	}
+
+	switch state {
+	case StateDownloading, StateBuilding:
+		pr.log.Infof("Installing: %s\n", utils.Color.Cyan(t.name))
+	case StateUpToDate:
+		pr.log.Infof("Up to date: %s\n", utils.Color.Cyan(t.name))
+	case StateDone:
+		pr.log.Infof("Installed: %s in %s\n", utils.Color.Cyan(t.name), formatElapsed(t.elapsed))
+	case StateFailed:
+		pr.log.Infof("Failed: %s after %s\n", utils.Color.Cyan(t.name), formatElapsed(t.elapsed))
+	}
+}
+
+// OnStart shows the package downloading, or building.
+func (pr *Progress) OnStart(e *Event) {
+	if e.step.Release != nil {
+		pr.set(e.step, StateDownloading)
+	} else {
+		pr.set(e.step, StateBuilding)
+	}
+}
+
+// OnOutput ignores the output of commands, which is logged.
+func (pr *Progress) OnOutput(e *Event) {}
+
+// OnSuccess shows the package done.
+func (pr *Progress) OnSuccess(e *Event) { pr.set(e.step, StateDone) }
+
+// OnFailure shows the package failed.
+func (pr *Progress) OnFailure(e *Event) { pr.set(e.step, StateFailed) }
+
+// OnSkip shows the package up to date.
+func (pr *Progress) OnSkip(e *Event) { pr.set(e.step, StateUpToDate) }
+
+// Write writes log output above the packages redrawn on the terminal.
+func (pr *Progress) Write(b []byte) (int, error) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	pr.clear()
+	n, err := pr.term.Write(b)
+	pr.drawn = 0
+	pr.redraw()
+
+	return n, err
+}
+
+// OnFinish stops redrawing, and logs the summary of the installs, unless
+// nothing was planned.
+func (pr *Progress) OnFinish(results []*Result, err error) error {
+	if pr.animating {
+		close(pr.stop)
+		pr.wg.Wait()
+		pr.animating = false
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	if pr.start.IsZero() {
+		return nil
+	}
+
+	if pr.term != nil {
+		pr.redraw()
+	}
+
+	counts := make(map[string]int)
+	for _, t := range pr.tasks {
+		counts[t.state]++
+	}
+
+	summary := fmt.Sprintf("%d installed, %d up to date", counts[StateDone], counts[StateUpToDate])
+	if counts[StateFailed] > 0 {
+		summary += fmt.Sprintf(", %d failed", counts[StateFailed])
+	}
+	if counts[StateQueued] > 0 {
+		summary += fmt.Sprintf(", %d not installed", counts[StateQueued])
+	}
+	pr.log.Infof("%s in %s\n", summary, formatElapsed(time.Since(pr.start)))
+
+	return nil
+}
+
+// clear moves the cursor to the first line drawn, and clears the lines below.
+func (pr *Progress) clear() {
+	if pr.drawn > 0 {
+		fmt.Fprintf(pr.term, "\x1b[%dA\x1b[J", pr.drawn)
+	}
+}
+
+// redraw draws one line per package over the lines previously drawn.
+func (pr *Progress) redraw() {
+	width := 0
+	for _, t := range pr.tasks {
+		if len(t.name) > width {
+			width = len(t.name)
+		}
+	}
+
+	var b strings.Builder
+	if pr.drawn > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", pr.drawn)
+	}
+	for _, t := range pr.tasks {
+		fmt.Fprintf(&b, "\r\x1b[K%s %-*s  %s\n", pr.symbol(t), width, t.name, pr.status(t))
+	}
+	io.WriteString(pr.term, b.String())
+	pr.drawn = len(pr.tasks)
+}
+
+// symbol returns the symbol shown before the provided package.
+func (pr *Progress) symbol(t *task) string {
+	switch t.state {
+	case StateDownloading, StateBuilding:
+		return utils.Color.Cyan(progressFrames[pr.frame%len(progressFrames)]).String()
+	case StateDone, StateUpToDate:
+		return utils.Color.Green("✓").String()
+	case StateFailed:
+		return utils.Color.Red("✗").String()
+	default:
+		return " "
+	}
+}
+
+// status returns the state of the provided package, and its elapsed time.
+func (pr *Progress) status(t *task) string {
+	switch t.state {
+	case StateDownloading, StateBuilding:
+		return fmt.Sprintf("%s %s", t.state, formatElapsed(time.Since(t.start)))
+	case StateDone, StateFailed:
+		return fmt.Sprintf("%s %s", t.state, formatElapsed(t.elapsed))
+	default:
+		return t.state
+	}
+}
+
+// formatElapsed returns the provided duration rounded to a tenth of a second.
+func formatElapsed(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/retr0h/gofile/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressRedrawsTerminal(t *testing.T) {
+	var term, out bytes.Buffer
+	steps := []*Step{
+		{URL: "github.com/simeji/jid/cmd/jid"},
+		{URL: "golang.org/x/lint/golint"},
+	}
+	pr := NewProgress(&utils.Logger{Level: utils.LevelInfo, Out: &out}, &term)
+	pr.OnPlan(steps)
+	pr.set(steps[0], StateBuilding)
+	pr.Write([]byte("Locking: github.com/simeji/jid\n"))
+	pr.set(steps[0], StateDone)
+	pr.set(steps[1], StateFailed)
+	pr.OnFinish(nil, nil)
+	got := term.String()
+
+	assert.Contains(t, got, "\r\x1b[K  golang.org/x/lint/golint       queued\n")
+	assert.Contains(t, got, "\x1b[2A\x1b[JLocking: github.com/simeji/jid\n")
+	assert.Regexp(t, "\r\x1b\\[K✓ github.com/simeji/jid/cmd/jid  done [0-9.]+s\n\r\x1b\\[K✗ golang.org/x/lint/golint       failed [0-9.]+s\n$", got)
+	assert.Regexp(t, "^1 installed, 0 up to date, 1 failed in [0-9.]+s\n$", out.String())
+}
+
+func TestProgressLogsPlainLines(t *testing.T) {
+	var out bytes.Buffer
+	steps := []*Step{
+		{URL: "github.com/simeji/jid/cmd/jid"},
+		{Release: &Release{URL: "https://example.com/jid.zip"}},
+		{URL: "golang.org/x/lint/golint"},
+	}
+	pr := NewProgress(&utils.Logger{Level: utils.LevelInfo, Out: &out}, nil)
+	pr.OnPlan(steps)
+	pr.set(steps[0], StateUpToDate)
+	pr.set(steps[1], StateDownloading)
+	pr.set(steps[1], StateDone)
+	pr.OnFinish(nil, nil)
+	want := `^Up to date: github.com/simeji/jid/cmd/jid
+Installing: https://example.com/jid.zip
+Installed: https://example.com/jid.zip in [0-9.]+s
+1 installed, 1 up to date, 1 not installed in [0-9.]+s
+$`
+
+	assert.Regexp(t, want, out.String())
+}
+
+func TestProgressReusedAcrossInstalls(t *testing.T) {
+	var term, out bytes.Buffer
+	pr := NewProgress(&utils.Logger{Level: utils.LevelInfo, Out: &out}, &term)
+	for _, url := range []string{"github.com/simeji/jid/cmd/jid", "golang.org/x/lint/golint"} {
+		step := &Step{URL: url}
+		pr.OnPlan([]*Step{step})
+		pr.set(step, StateBuilding)
+		pr.set(step, StateDone)
+		pr.OnFinish(nil, nil)
+	}
+
+	assert.Regexp(t, "^(1 installed, 0 up to date in [0-9.]+s\n){2}$", out.String())
+}
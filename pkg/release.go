@@ -0,0 +1,331 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+var (
+	httpClient = http.DefaultClient
+)
+
+// Release containing the details of a prebuilt binary published as a release
+// asset.  The URL and Checksums are templates, expanded with the `OS`, `Arch`
+// and `Version` of the package.
+type Release struct {
+	URL       string `yaml:"url" json:"url"`                       // URL of the asset, a tar.gz, zip, or the binary itself.
+	Checksums string `yaml:"checksums" json:"checksums,omitempty"` // Checksums of the assets in `sha256sum` format.
+	Binary    string `yaml:"binary" json:"binary"`                 // Binary to extract from the asset.
+}
+
+// releaseData containing the values release templates are expanded with.
+type releaseData struct {
+	OS      string
+	Arch    string
+	Version string
+}
+
+// planRelease completes the provided step with the release of the package,
+// expanding its templates for the running platform.
+func (p *Packages) planRelease(step *Step, pkg Package) (*Step, error) {
+	data := releaseData{
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Version: pkg.Version,
+	}
+
+	assetURL, err := expandTemplate(pkg.Release.URL, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if pkg.Release.Checksums == "" && pkg.SHA256 == "" {
+		return nil, &ValidationError{Errors: []string{
+			fmt.Sprintf("release '%s' requires checksums or a sha256", pkg.Release.URL),
+		}}
+	}
+
+	checksums, err := expandTemplate(pkg.Release.Checksums, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Releases are downloaded rather than built, so are never isolated.
+	step.Isolated = false
+	step.Release = &Release{
+		URL:       assetURL,
+		Checksums: checksums,
+		Binary:    pkg.Release.Binary,
+	}
+	step.Binary = filepath.Join(step.BinDir, binaryName(step))
+
+	return step, nil
+}
+
+// expandTemplate executes the provided text template with data.
+func expandTemplate(text string, data releaseData) (string, error) {
+	tmpl, err := template.New("release").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// statusError is returned when a download responds with a status other
+// than OK.
+type statusError struct {
+	url    string
+	status string
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("GET %s: %s", e.url, e.status)
+}
+
+// installRelease downloads the step's release asset, verifies it against the
+// published checksums and the package's checksum, and installs the binary it
+// contains, retrying transient failures up to the step's retries.
+func (p *Packages) installRelease(ctx context.Context, step *Step) error {
+	return p.retry(ctx, step, p.attemptRelease)
+}
+
+// attemptRelease installs the release of the provided step once, bounded by
+// the step's timeout.
+func (p *Packages) attemptRelease(ctx context.Context, step *Step) error {
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
+	}
+
+	err := p.downloadRelease(ctx, step)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return &timeoutError{timeout: step.Timeout}
+	}
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+// downloadRelease downloads the step's release asset, verifies it, and
+// installs the binary it contains.
+func (p *Packages) downloadRelease(ctx context.Context, step *Step) error {
+	p.log().Debugf("DOWNLOAD: %s\n", step.Release.URL)
+
+	asset, err := ioutil.TempFile("", "gofile")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(asset.Name())
+	defer asset.Close()
+
+	h := sha256.New()
+	if err := download(ctx, step.Release.URL, io.MultiWriter(asset, h)); err != nil {
+		return err
+	}
+
+	got := hex.EncodeToString(h.Sum(nil))
+	if step.SHA256 != "" {
+		if err := verifyChecksum(step.Release.URL, step.SHA256, got); err != nil {
+			return err
+		}
+	}
+
+	if step.Release.Checksums != "" {
+		want, err := releaseChecksum(ctx, step.Release)
+		if err != nil {
+			return err
+		}
+
+		if err := verifyChecksum(step.Release.URL, want, got); err != nil {
+			return err
+		}
+	}
+
+	return extractBinary(asset, step.Release, step.Binary)
+}
+
+// download writes the body of the provided URL to w.
+func download(ctx context.Context, rawurl string, w io.Writer) error {
+	req, err := http.NewRequest(http.MethodGet, rawurl, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &statusError{url: rawurl, status: resp.Status, code: resp.StatusCode}
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// releaseChecksum returns the checksum of the release's asset, found in its
+// published checksums.
+func releaseChecksum(ctx context.Context, release *Release) (string, error) {
+	var buf bytes.Buffer
+	if err := download(ctx, release.Checksums, &buf); err != nil {
+		return "", err
+	}
+
+	name, err := assetName(release.URL)
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+
+	return "", fmt.Errorf("no checksum for '%s' in '%s'", name, release.Checksums)
+}
+
+// assetName returns the file name of the asset at the provided URL.
+func assetName(rawurl string) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Base(u.Path), nil
+}
+
+// extractBinary installs the release's binary from the provided asset to
+// dst.  Assets which are neither a tar.gz or zip are the binary itself.
+func extractBinary(asset *os.File, release *Release, dst string) error {
+	if _, err := asset.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	name, err := assetName(release.URL)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return extractTarGz(asset, release.Binary, dst)
+	case strings.HasSuffix(name, ".zip"):
+		return extractZip(asset, release.Binary, dst)
+	default:
+		return writeBinary(asset, dst)
+	}
+}
+
+// extractTarGz installs the named binary from the tar.gz archive to dst.
+func extractTarGz(r io.Reader, binary string, dst string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("binary '%s' not found in archive", binary)
+		}
+		if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag == tar.TypeReg && matchesBinary(hdr.Name, binary) {
+			return writeBinary(tr, dst)
+		}
+	}
+}
+
+// extractZip installs the named binary from the zip archive to dst.
+func extractZip(f *os.File, binary string, dst string) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	zr, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		return err
+	}
+
+	for _, zf := range zr.File {
+		if zf.Mode().IsRegular() && matchesBinary(zf.Name, binary) {
+			rc, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+
+			return writeBinary(rc, dst)
+		}
+	}
+
+	return fmt.Errorf("binary '%s' not found in archive", binary)
+}
+
+// matchesBinary returns true when the archive entry is the named binary.
+// Binaries named without a directory match an entry in any directory.
+func matchesBinary(entry string, binary string) bool {
+	entry = strings.TrimPrefix(entry, "./")
+	if strings.Contains(binary, "/") {
+		return entry == binary
+	}
+
+	return path.Base(entry) == binary
+}
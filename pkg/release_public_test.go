@@ -0,0 +1,305 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/retr0h/gofile/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanRelease(t *testing.T) {
+	data := `
+---
+- release:
+    url: https://example.com/v{{.Version}}/jid_{{.OS}}_{{.Arch}}.zip
+    checksums: https://example.com/v{{.Version}}/checksums.txt
+    binary: jid
+  version: 0.7.6
+`
+	defer setenv("GOBIN", "/gobin")()
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	got, err := p.Plan()
+	want := &pkg.Release{
+		URL:       fmt.Sprintf("https://example.com/v0.7.6/jid_%s_%s.zip", runtime.GOOS, runtime.GOARCH),
+		Checksums: "https://example.com/v0.7.6/checksums.txt",
+		Binary:    "jid",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got[0].Release)
+	assert.Nil(t, got[0].Command)
+	assert.Equal(t, "/gobin/jid", got[0].Binary)
+}
+
+func TestPlanReleaseReturnsErrorWithInvalidTemplate(t *testing.T) {
+	data := `
+---
+- release:
+    url: https://example.com/{{.Foo}}.zip
+    checksums: https://example.com/checksums.txt
+    binary: jid
+`
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	_, err := p.Plan()
+
+	assert.Error(t, err)
+}
+
+func TestInstallReleaseTarGz(t *testing.T) {
+	asset := tarGz(t, map[string]string{
+		"jid_0.7.6/README.md": "readme",
+		"jid_0.7.6/jid":       "binary",
+	})
+	got, err := installRelease(t, "jid.tar.gz", asset, checksums("jid.tar.gz", asset), "jid")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "binary", got)
+}
+
+func TestInstallReleaseZip(t *testing.T) {
+	asset := zipArchive(t, map[string]string{
+		"README.md": "readme",
+		"bin/jid":   "binary",
+	})
+	got, err := installRelease(t, "jid.zip", asset, checksums("jid.zip", asset), "bin/jid")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "binary", got)
+}
+
+func TestInstallReleaseBinary(t *testing.T) {
+	asset := []byte("binary")
+	got, err := installRelease(t, "jid", asset, checksums("jid", asset), "jid")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "binary", got)
+}
+
+func TestInstallReleaseWithSHA256(t *testing.T) {
+	asset := []byte("binary")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(asset)
+	}))
+	defer ts.Close()
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	data := `
+---
+- release:
+    url: ` + ts.URL + `/jid
+    binary: jid
+  sha256: ` + fmt.Sprintf("%x", sha256.Sum256(asset)) + `
+- release:
+    url: ` + ts.URL + `/other
+    binary: other
+  sha256: ` + fmt.Sprintf("%x", sha256.Sum256([]byte("other"))) + `
+`
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch for '"+ts.URL+"/other'")
+	assert.True(t, fileExists(filepath.Join(dir, "jid")))
+	assert.False(t, fileExists(filepath.Join(dir, "other")))
+}
+
+func TestPlanReleaseReturnsErrorWithoutChecksums(t *testing.T) {
+	data := `
+---
+- release:
+    url: https://example.com/jid
+    binary: jid
+`
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	_, err := p.Plan()
+	want := "release 'https://example.com/jid' requires checksums or a sha256"
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestInstallReleaseReturnsErrorWithChecksumMismatch(t *testing.T) {
+	asset := []byte("binary")
+	_, err := installRelease(t, "jid", asset, checksums("jid", []byte("other")), "jid")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch for")
+}
+
+func TestInstallReleaseReturnsErrorWithoutChecksum(t *testing.T) {
+	asset := []byte("binary")
+	_, err := installRelease(t, "jid", asset, checksums("other", asset), "jid")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no checksum for 'jid' in")
+}
+
+func TestInstallReleaseReturnsErrorWhenBinaryMissing(t *testing.T) {
+	asset := tarGz(t, map[string]string{"README.md": "readme"})
+	_, err := installRelease(t, "jid.tar.gz", asset, checksums("jid.tar.gz", asset), "jid")
+
+	assert.Error(t, err)
+	assert.Equal(t, "binary 'jid' not found in archive", errors.Unwrap(err).Error())
+}
+
+func TestInstallReleaseReturnsErrorWhenDownloadFails(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	data := `
+---
+- release:
+    url: ` + ts.URL + `/jid
+    checksums: ` + ts.URL + `/checksums.txt
+    binary: jid
+`
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+	want := fmt.Sprintf("installing '%s/jid' failed: GET %s/jid: 404 Not Found", ts.URL, ts.URL)
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestInstallReleaseRetriesTransientErrors(t *testing.T) {
+	asset := []byte("binary")
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(asset)
+	}))
+	defer ts.Close()
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	data := `
+---
+- release:
+    url: ` + ts.URL + `/jid
+    binary: jid
+  sha256: ` + fmt.Sprintf("%x", sha256.Sum256(asset)) + `
+  retries: 1
+`
+	p := pkg.Packages{
+		Logger:  &utils.Logger{Level: utils.LevelError},
+		Backoff: time.Millisecond,
+	}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+	got, _ := ioutil.ReadFile(filepath.Join(dir, "jid"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, "binary", string(got))
+}
+
+// installRelease installs the provided asset and checksums from a local HTTP
+// server, and returns the contents of the installed binary.
+func installRelease(t *testing.T, name string, asset []byte, sums string, binary string) (string, error) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download/" + name:
+			w.Write(asset)
+		case "/download/checksums.txt":
+			w.Write([]byte(sums))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", dir)()
+	data := `
+---
+- release:
+    url: ` + ts.URL + `/download/` + name + `
+    checksums: ` + ts.URL + `/download/checksums.txt
+    binary: ` + binary + `
+`
+	p := pkg.Packages{}
+	p.UnmarshalYAML([]byte(data))
+	err := p.Install(context.Background())
+	got, _ := ioutil.ReadFile(filepath.Join(dir, filepath.Base(binary)))
+
+	return string(got), err
+}
+
+// checksums returns a checksums file for the provided asset.
+func checksums(name string, asset []byte) string {
+	return fmt.Sprintf("%x  %s\n", sha256.Sum256(asset), name)
+}
+
+// tarGz returns a tar.gz archive containing the provided files.
+func tarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		hdr := &tar.Header{Name: name, Mode: 0755, Size: int64(len(body)), Typeflag: tar.TypeReg}
+		assert.NoError(t, tw.WriteHeader(hdr))
+		tw.Write([]byte(body))
+	}
+	tw.Close()
+	gz.Close()
+
+	return buf.Bytes()
+}
+
+// zipArchive returns a zip archive containing the provided files.
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		w.Write([]byte(body))
+	}
+	zw.Close()
+
+	return buf.Bytes()
+}
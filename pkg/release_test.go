@@ -0,0 +1,51 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesBinary(t *testing.T) {
+	assert.True(t, matchesBinary("jid", "jid"))
+	assert.True(t, matchesBinary("./jid", "jid"))
+	assert.True(t, matchesBinary("jid_0.7.6/jid", "jid"))
+	assert.True(t, matchesBinary("bin/jid", "bin/jid"))
+	assert.False(t, matchesBinary("other/jid", "bin/jid"))
+	assert.False(t, matchesBinary("jid.md", "jid"))
+}
+
+func TestAssetName(t *testing.T) {
+	got, err := assetName("https://example.com/download/jid.tar.gz?raw=true")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "jid.tar.gz", got)
+}
+
+func TestExpandTemplate(t *testing.T) {
+	data := releaseData{OS: "linux", Arch: "amd64", Version: "1.0.0"}
+	got, err := expandTemplate("jid_{{.Version}}_{{.OS}}_{{.Arch}}.tar.gz", data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "jid_1.0.0_linux_amd64.tar.gz", got)
+}
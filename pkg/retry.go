@@ -0,0 +1,101 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// defaultBackoff is the delay before the first retry, when `Packages.Backoff`
+// is not set.
+const defaultBackoff = time.Second
+
+// transientPattern matches the stderr of `go` commands which failed for
+// reasons likely to go away on their own, such as flaky proxy connections.
+var transientPattern = regexp.MustCompile(`(?i)(i/o timeout|TLS handshake timeout|timeout awaiting response headers|` +
+	`Client\.Timeout exceeded|connection timed out|connection reset|connection refused|temporary failure|` +
+	`unexpected EOF|: 5\d\d\b)`)
+
+// timeoutError is returned when an attempt at installing a package exceeds
+// the package's timeout.
+type timeoutError struct {
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.timeout)
+}
+
+// isTransient returns true when the provided error was caused by a command
+// or download failing with a transient network error, or timing out.
+func isTransient(err error) bool {
+	var (
+		timeoutErr *timeoutError
+		statusErr  *statusError
+		netErr     net.Error
+	)
+
+	switch {
+	case errors.As(err, &timeoutErr), errors.As(err, &netErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	case errors.As(err, &statusErr):
+		return statusErr.code >= http.StatusInternalServerError
+	}
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+
+	return transientPattern.MatchString(cmdErr.Stderr)
+}
+
+// backoff returns the delay before the provided retry, which doubles for
+// each attempt made.
+func (p *Packages) backoff(retry int) time.Duration {
+	delay := p.Backoff
+	if delay == 0 {
+		delay = defaultBackoff
+	}
+
+	return delay << uint(retry-1)
+}
+
+// sleep waits for the provided duration, and returns early with the
+// context's error when it is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTransient(t *testing.T) {
+	stderrs := []string{
+		"dial tcp 10.0.0.1:443: i/o timeout",
+		"net/http: TLS handshake timeout",
+		"read tcp 10.0.0.1:443: read: connection reset by peer",
+		"reading https://proxy.golang.org/golang.org/x/lint/@v/list: 502 Bad Gateway",
+		"unexpected EOF",
+	}
+	for _, stderr := range stderrs {
+		err := &CommandError{Err: errors.New("exit status 1"), Stderr: stderr}
+
+		assert.True(t, isTransient(err), stderr)
+	}
+}
+
+func TestIsTransientWhenTimedOut(t *testing.T) {
+	assert.True(t, isTransient(&timeoutError{timeout: time.Second}))
+}
+
+func TestIsTransientWhenDownloadFails(t *testing.T) {
+	assert.True(t, isTransient(&statusError{url: "https://example.com/jid", status: "503 Service Unavailable", code: 503}))
+	assert.False(t, isTransient(&statusError{url: "https://example.com/jid", status: "404 Not Found", code: 404}))
+}
+
+func TestIsTransientReturnsFalse(t *testing.T) {
+	errs := []error{
+		errors.New("connection reset by peer"),
+		&CommandError{Err: errors.New("exit status 1"), Stderr: "cannot find package \"invalid.\""},
+		&CommandError{Err: errors.New("exit status 1"), Stderr: "reading https://proxy.golang.org/foo: 404 Not Found"},
+		&CommandError{Err: errors.New("exit status 1"), Stderr: "go: invalid timeout value \"soon\""},
+	}
+	for _, err := range errs {
+		assert.False(t, isTransient(err), err.Error())
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := Packages{
+		Backoff: 100 * time.Millisecond,
+	}
+
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 400*time.Millisecond, p.backoff(3))
+}
+
+func TestBackoffDefault(t *testing.T) {
+	p := Packages{}
+
+	assert.Equal(t, time.Second, p.backoff(1))
+}
+
+func TestSleepReturnsErrorWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := sleep(ctx, time.Hour)
+
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// waitDelay is how long a cancelled command's process group has to exit after
+// being signalled, before it is killed.
+const waitDelay = 5 * time.Second
+
+// stderrTailLines is how many lines of a failed command's stderr its error
+// includes.
+const stderrTailLines = 10
+
+// Command containing the details of a command to be executed by a `Runner`.
+type Command struct {
+	Name string   `json:"name"`          // Name of the program to execute.
+	Args []string `json:"args"`          // Args passed to the program.
+	Env  []string `json:"env,omitempty"` // Env appended to the environment of gofile.
+	Dir  string   `json:"dir,omitempty"` // Dir to run the program from, current directory when empty.
+
+	// Stdout receives the program's stdout rather than the runner, when
+	// the output is needed rather than logged.
+	Stdout io.Writer `json:"-"`
+}
+
+// String returns the command as it would be typed into a shell.
+func (c *Command) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Runner is the interface that wraps the Run method.
+//
+// Run executes the provided command, and returns an error when the command
+// could not be started or did not complete successfully.  Implementations
+// must stop the command when the provided context is done.
+type Runner interface {
+	Run(ctx context.Context, cmd *Command) error
+}
+
+// ExecRunner executes commands on the host with os/exec.
+type ExecRunner struct {
+	Stdout io.Writer // Stdout receives the command's stdout, discarded when nil.
+	Stderr io.Writer // Stderr receives the command's stderr, discarded when nil.
+}
+
+// Run executes the provided command in its own process group.  When the
+// context is done the whole process group is terminated, so children spawned
+// by the command (e.g. the compilers and VCS tools started by `go get`) do
+// not outlive it.
+func (r *ExecRunner) Run(ctx context.Context, cmd *Command) error {
+	c := exec.CommandContext(ctx, cmd.Name, cmd.Args...)
+	setProcessGroup(c)
+	c.WaitDelay = waitDelay
+	c.Dir = cmd.Dir
+	if len(cmd.Env) > 0 {
+		c.Env = append(os.Environ(), cmd.Env...)
+	}
+	c.Stdout = r.Stdout
+	if cmd.Stdout != nil {
+		c.Stdout = cmd.Stdout
+	}
+
+	// Keep a copy of stderr, so failures can be inspected by the caller.
+	var stderr bytes.Buffer
+	c.Stderr = &stderr
+	if r.Stderr != nil {
+		c.Stderr = io.MultiWriter(&stderr, r.Stderr)
+	}
+
+	if err := c.Run(); err != nil {
+		return &CommandError{Err: err, Stderr: stderr.String()}
+	}
+
+	return nil
+}
+
+// CommandError is returned by `ExecRunner` when a command fails, and carries
+// the stderr the command produced.
+type CommandError struct {
+	Err    error  // Err returned by os/exec.
+	Stderr string // Stderr of the failed command.
+}
+
+// Error returns the error returned by os/exec, followed by the last lines
+// of the command's stderr.
+func (e *CommandError) Error() string {
+	stderr := tail(e.Stderr, stderrTailLines)
+	if stderr == "" {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%s: %s", e.Err, stderr)
+}
+
+// Unwrap returns the error returned by os/exec.
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// tail returns the last n lines of s, marking any lines omitted.
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) <= n {
+		return strings.Join(lines, "\n")
+	}
+
+	return "...\n" + strings.Join(lines[len(lines)-n:], "\n")
+}
+
+// RecordRunner records the provided commands without executing them.
+type RecordRunner struct {
+	Commands []*Command // Commands in the order they were run.
+}
+
+// Run records the provided command.
+func (r *RecordRunner) Run(ctx context.Context, cmd *Command) error {
+	r.Commands = append(r.Commands, cmd)
+
+	return nil
+}
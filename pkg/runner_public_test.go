@@ -0,0 +1,126 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandString(t *testing.T) {
+	cmd := &pkg.Command{Name: "go", Args: []string{"get", "-v", "github.com/simeji/jid/cmd/jid"}}
+	want := "go get -v github.com/simeji/jid/cmd/jid"
+
+	assert.Equal(t, want, cmd.String())
+}
+
+func TestExecRunnerRun(t *testing.T) {
+	var stdout bytes.Buffer
+	r := &pkg.ExecRunner{Stdout: &stdout}
+	err := r.Run(context.Background(), &pkg.Command{Name: "echo", Args: []string{"-n", "foo"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", stdout.String())
+}
+
+func TestExecRunnerRunReturnsError(t *testing.T) {
+	var stderr bytes.Buffer
+	r := &pkg.ExecRunner{Stderr: &stderr}
+	err := r.Run(context.Background(), &pkg.Command{Name: "cat", Args: []string{"foo"}})
+
+	assert.Error(t, err)
+	assert.Equal(t, "cat: foo: No such file or directory\n", stderr.String())
+	assert.Equal(t, "cat: foo: No such file or directory\n", err.(*pkg.CommandError).Stderr)
+	assert.Equal(t, "exit status 1: cat: foo: No such file or directory", err.Error())
+}
+
+func TestRecordRunnerRun(t *testing.T) {
+	r := &pkg.RecordRunner{}
+	cmd := &pkg.Command{Name: "false"}
+	err := r.Run(context.Background(), cmd)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*pkg.Command{cmd}, r.Commands)
+}
+
+func TestExecRunnerRunWithEnvAndDir(t *testing.T) {
+	var stdout bytes.Buffer
+	r := &pkg.ExecRunner{Stdout: &stdout}
+	err := r.Run(context.Background(), &pkg.Command{
+		Name: "sh",
+		Args: []string{"-c", "echo -n $FOO $(pwd)"},
+		Env:  []string{"FOO=bar"},
+		Dir:  "/",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "bar /", stdout.String())
+}
+
+func TestExecRunnerRunTerminatesProcessGroupWhenContextDone(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "child")
+
+	r := &pkg.ExecRunner{}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := r.Run(ctx, &pkg.Command{
+		Name: "sh",
+		Args: []string{"-c", "(sleep 0.5; touch " + file + ") & wait"},
+	})
+
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	// The backgrounded child was terminated along with the shell.
+	time.Sleep(time.Second)
+	assert.False(t, fileExists(file))
+}
+
+func TestCommandErrorIncludesStderrTail(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 12; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	err := &pkg.CommandError{Err: errors.New("exit status 1"), Stderr: strings.Join(lines, "\n") + "\n"}
+	want := "exit status 1: ...\n" + strings.Join(lines[2:], "\n")
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestCommandErrorWithoutStderr(t *testing.T) {
+	err := &pkg.CommandError{Err: errors.New("exit status 1")}
+
+	assert.Equal(t, "exit status 1", err.Error())
+}
@@ -0,0 +1,246 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// State containing what gofile installed, persisted as JSON between runs.
+type State struct {
+	Installed map[string]*Installed `json:"installed"` // Installed binaries keyed by path.
+}
+
+// Installed containing the details of a binary installed by gofile.
+type Installed struct {
+	URL         string       `json:"url"`                // URL of the package, its local path, or its download URL.
+	Version     string       `json:"version,omitempty"`  // Version of the module which built the binary, or the release.
+	Binary      string       `json:"binary"`             // Binary installed.
+	SHA256      string       `json:"sha256"`             // SHA256 of the binary when installed.
+	Go          string       `json:"go,omitempty"`       // Go release which built the binary.
+	InstalledAt time.Time    `json:"installed_at"`       // InstalledAt is the time the binary was installed.
+	Manifest    string       `json:"manifest,omitempty"` // Manifest is the gofile the package was installed from.
+	Path        string       `json:"path,omitempty"`     // Path of the binary within the store, the binary links to it.
+	Previous    []*Installed `json:"previous,omitempty"` // Previous binaries kept for rollbacks, most recent first.
+}
+
+// now returns the current time, stubbed by tests.
+var now = time.Now
+
+// DefaultStateFile returns the location of the state file, which follows the
+// XDG base directory specification.
+func DefaultStateFile() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(dir, "gofile", "state.json"), nil
+}
+
+// LoadState reads the state from the named file, returning an empty state
+// when the file does not exist.
+func LoadState(filename string) (*State, error) {
+	s := &State{Installed: make(map[string]*Installed)}
+
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Installed == nil {
+		s.Installed = make(map[string]*Installed)
+	}
+
+	return s, nil
+}
+
+// List returns the installed binaries sorted by binary, limited to those
+// named by the provided names when any are provided.  A name matches the
+// binary's file name, or the package's URL.
+func (s *State) List(names ...string) []*Installed {
+	list := []*Installed{}
+	for _, installed := range s.Installed {
+		if len(names) == 0 || installed.matches(names) {
+			list = append(list, installed)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Binary < list[j].Binary
+	})
+
+	return list
+}
+
+// matches returns true when any of the provided names names the binary.
+func (i *Installed) matches(names []string) bool {
+	base := strings.TrimSuffix(filepath.Base(i.Binary), ".exe")
+	for _, name := range names {
+		if name == base || name == i.URL {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PrintInstalled writes the provided installed binaries to w in the
+// requested format.
+func PrintInstalled(w io.Writer, list []*Installed, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(list)
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, installed := range list {
+			if err := enc.Encode(installed); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case FormatText:
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "BINARY\tVERSION\tGO\tINSTALLED\tURL")
+		for _, installed := range list {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+				installed.Binary,
+				orDash(installed.Version),
+				orDash(installed.Go),
+				installed.InstalledAt.Format(time.RFC3339),
+				installed.URL)
+		}
+
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown format '%s'", format)
+	}
+}
+
+// orDash returns the provided value, or a dash when empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+// Save writes the state to the named file, replacing it atomically.
+func (s *State) Save(filename string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
+
+// record hashes the step's installed binary into the state file, along with
+// the module version and go release read from the binary, when
+// `Packages.StateFile` is set.  The binaries it replaced are kept for
+// rollbacks when stored.
+func (p *Packages) record(step *Step) error {
+	if p.StateFile == "" {
+		return nil
+	}
+
+	sum, err := hashFile(step.Binary)
+	if err != nil {
+		return err
+	}
+
+	s, err := LoadState(p.StateFile)
+	if err != nil {
+		return err
+	}
+	installed := &Installed{
+		URL:         step.Name(),
+		Version:     step.Version,
+		Binary:      step.Binary,
+		SHA256:      sum,
+		InstalledAt: now().UTC(),
+		Manifest:    p.file,
+	}
+
+	// Released binaries may not be built by go, or may be stripped.
+	if info, err := readBuildInfo(step.Binary); err == nil {
+		installed.Go = info.GoVersion
+		if info.Main.Version != "" && info.Main.Version != "(devel)" {
+			installed.Version = info.Main.Version
+		}
+	}
+
+	if p.StoreDir != "" {
+		if installed.Path, err = p.storePath(step.Binary, sum); err != nil {
+			return err
+		}
+	}
+
+	if replaced, ok := s.Installed[step.Binary]; ok {
+		installed.Previous = p.history(installed, replaced)
+	}
+	s.Installed[step.Binary] = installed
+
+	return s.Save(p.StateFile)
+}
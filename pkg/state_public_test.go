@@ -0,0 +1,140 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultStateFile(t *testing.T) {
+	defer setenv("XDG_STATE_HOME", "/state")()
+	got, err := pkg.DefaultStateFile()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/state/gofile/state.json", got)
+}
+
+func TestDefaultStateFileInHome(t *testing.T) {
+	defer setenv("XDG_STATE_HOME", "")()
+	defer setenv("HOME", "/home/user")()
+	got, err := pkg.DefaultStateFile()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/home/user/.local/state/gofile/state.json", got)
+}
+
+func TestLoadStateWithMissingFile(t *testing.T) {
+	s, err := pkg.LoadState("/missing/state.json")
+
+	assert.NoError(t, err)
+	assert.Empty(t, s.Installed)
+}
+
+func TestLoadStateReturnsErrorWithInvalidFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "state.json")
+	ioutil.WriteFile(filename, []byte("{"), 0644)
+
+	_, err := pkg.LoadState(filename)
+
+	assert.Error(t, err)
+}
+
+func TestStateSave(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "gofile", "state.json")
+	s := &pkg.State{Installed: map[string]*pkg.Installed{
+		"/gobin/jid": {SHA256: "abc"},
+	}}
+
+	err := s.Save(filename)
+	got, _ := pkg.LoadState(filename)
+
+	assert.NoError(t, err)
+	assert.Equal(t, s, got)
+}
+
+func TestStateList(t *testing.T) {
+	s := &pkg.State{Installed: map[string]*pkg.Installed{
+		"/gobin/jid":    {URL: "github.com/simeji/jid/cmd/jid", Binary: "/gobin/jid"},
+		"/gobin/golint": {URL: "golang.org/x/lint/golint", Binary: "/gobin/golint"},
+	}}
+	got := s.List()
+
+	assert.Len(t, got, 2)
+	assert.Equal(t, "/gobin/golint", got[0].Binary)
+	assert.Equal(t, "/gobin/jid", got[1].Binary)
+}
+
+func TestStateListWithNames(t *testing.T) {
+	s := &pkg.State{Installed: map[string]*pkg.Installed{
+		"/gobin/jid":    {URL: "github.com/simeji/jid/cmd/jid", Binary: "/gobin/jid"},
+		"/gobin/golint": {URL: "golang.org/x/lint/golint", Binary: "/gobin/golint"},
+		"/gobin/gopls":  {URL: "golang.org/x/tools/gopls", Binary: "/gobin/gopls"},
+	}}
+	got := s.List("jid", "golang.org/x/tools/gopls")
+
+	assert.Len(t, got, 2)
+	assert.Equal(t, "/gobin/gopls", got[0].Binary)
+	assert.Equal(t, "/gobin/jid", got[1].Binary)
+}
+
+func TestPrintInstalled(t *testing.T) {
+	list := []*pkg.Installed{
+		{
+			URL:         "github.com/simeji/jid/cmd/jid",
+			Version:     "v0.7.6",
+			Binary:      "/gobin/jid",
+			Go:          "go1.20.3",
+			InstalledAt: time.Date(2021, 5, 8, 22, 21, 13, 0, time.UTC),
+		},
+		{
+			URL:         "./tools/lint",
+			Binary:      "/gobin/lint",
+			InstalledAt: time.Date(2021, 5, 9, 8, 0, 0, 0, time.UTC),
+		},
+	}
+	var buf bytes.Buffer
+	err := pkg.PrintInstalled(&buf, list, pkg.FormatText)
+	want := `BINARY       VERSION  GO        INSTALLED             URL
+/gobin/jid   v0.7.6   go1.20.3  2021-05-08T22:21:13Z  github.com/simeji/jid/cmd/jid
+/gobin/lint  -        -         2021-05-09T08:00:00Z  ./tools/lint
+`
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, buf.String())
+}
+
+func TestPrintInstalledReturnsErrorWithUnknownFormat(t *testing.T) {
+	err := pkg.PrintInstalled(&bytes.Buffer{}, nil, "yaml")
+
+	assert.Equal(t, "unknown format 'yaml'", err.Error())
+}
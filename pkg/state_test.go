@@ -0,0 +1,70 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	defer stubReadBuildInfo(jidSum)()
+	installedAt := time.Date(2021, 5, 8, 22, 21, 13, 0, time.UTC)
+	defer stubNow(installedAt)()
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	binary := filepath.Join(dir, "jid")
+	ioutil.WriteFile(binary, []byte("github.com/simeji/jid/cmd/jid"), 0755)
+	p := Packages{
+		StateFile: filepath.Join(dir, "state.json"),
+		file:      "/gofile.yml",
+	}
+	step := &Step{URL: "github.com/simeji/jid/cmd/jid", Binary: binary}
+
+	err := p.record(step)
+	s, _ := LoadState(p.StateFile)
+	want := &Installed{
+		URL:         "github.com/simeji/jid/cmd/jid",
+		Version:     "v0.7.6",
+		Binary:      binary,
+		SHA256:      "a8f7cd6a1e16e21aa52b61f7047127785867bbb982d441ceef2005355bec94b0",
+		Go:          "go1.20.3",
+		InstalledAt: installedAt,
+		Manifest:    "/gofile.yml",
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, s.Installed[binary])
+}
+
+// stubNow replaces the current time with the provided time, and returns a
+// function restoring the original.
+func stubNow(t time.Time) func() {
+	original := now
+	now = func() time.Time { return t }
+
+	return func() { now = original }
+}
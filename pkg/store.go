@@ -0,0 +1,153 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultStoreDir returns the directory keeping installed binaries for
+// rollbacks, which follows the XDG base directory specification.
+func DefaultStoreDir() (string, error) {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(dir, "gofile", "store"), nil
+}
+
+// store moves the step's installed binary into `Packages.StoreDir`, keyed by
+// its hash, and links it into the bin directory, when both
+// `Packages.StoreDir` and `Packages.StateFile` are set.  Without a state file
+// nothing could be rolled back to, or pruned from the store.
+func (p *Packages) store(step *Step) error {
+	if p.StoreDir == "" || p.StateFile == "" {
+		return nil
+	}
+
+	sum, err := hashFile(step.Binary)
+	if err != nil {
+		return err
+	}
+
+	dst, err := p.storePath(step.Binary, sum)
+	if err != nil {
+		return err
+	}
+
+	// The toolchain leaves an up to date binary, which is already linked.
+	if target, err := os.Readlink(step.Binary); err == nil && target == dst {
+		return nil
+	}
+
+	if _, err := os.Stat(dst); os.IsNotExist(err) {
+		if err := copyBinary(step.Binary, dst); err != nil {
+			return err
+		}
+	}
+
+	return link(dst, step.Binary)
+}
+
+// storePath returns the path the provided binary with the provided hash is
+// kept at within `Packages.StoreDir`.
+func (p *Packages) storePath(binary string, sum string) (string, error) {
+	dir, err := filepath.Abs(p.StoreDir)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(binary)
+
+	return filepath.Join(dir, name, sum[:16], name), nil
+}
+
+// history returns the binaries kept for rolling back the provided installed
+// binary, most recent first, starting with the binary it replaced.  Binaries
+// beyond `Packages.Keep` are removed from the store.
+func (p *Packages) history(installed *Installed, replaced *Installed) []*Installed {
+	keep := p.Keep
+	if keep < 1 {
+		keep = 1
+	}
+
+	var previous []*Installed
+	for _, i := range append([]*Installed{replaced}, replaced.Previous...) {
+		if i.Path == "" || i.SHA256 == installed.SHA256 {
+			continue
+		}
+
+		if len(previous) >= keep-1 {
+			os.RemoveAll(filepath.Dir(i.Path))
+			continue
+		}
+
+		kept := *i
+		kept.Previous = nil
+		previous = append(previous, &kept)
+	}
+
+	return previous
+}
+
+// Rollback links the binary installed before the named binary into the bin
+// directory.  The rolled back binary takes its place as the previous binary,
+// rolling back again restores it.
+func (s *State) Rollback(binary string) (*Installed, error) {
+	current, ok := s.Installed[binary]
+	if !ok {
+		return nil, fmt.Errorf("'%s' was not installed by gofile", binary)
+	}
+
+	if len(current.Previous) == 0 {
+		return nil, fmt.Errorf("'%s' has no previous binary to roll back to", binary)
+	}
+
+	previous := current.Previous[0]
+	if err := link(previous.Path, binary); err != nil {
+		return nil, err
+	}
+
+	rolledBack := *current
+	rolledBack.Previous = nil
+	previous.Previous = append([]*Installed{&rolledBack}, current.Previous[1:]...)
+	s.Installed[binary] = previous
+
+	return previous, nil
+}
+
+// link replaces the named binary with a symlink to target atomically.
+func link(target string, binary string) error {
+	tmp := filepath.Join(filepath.Dir(binary), "."+filepath.Base(binary)+".link")
+	os.Remove(tmp)
+
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, binary)
+}
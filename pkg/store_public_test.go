@@ -0,0 +1,151 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultStoreDir(t *testing.T) {
+	defer setenv("XDG_DATA_HOME", "/data")()
+	got, err := pkg.DefaultStoreDir()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/data/gofile/store", got)
+}
+
+func TestInstallStoresBinaries(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", filepath.Join(dir, "bin"))()
+	p := installFrom(t, dir, "github.com/a/jid", 3)
+	installFrom(t, dir, "github.com/b/jid", 3)
+	installFrom(t, dir, "github.com/c/jid", 3)
+	binary := filepath.Join(dir, "bin", "jid")
+	s, _ := pkg.LoadState(p.StateFile)
+	got, _ := ioutil.ReadFile(binary)
+	target, _ := os.Readlink(binary)
+
+	assert.Equal(t, "github.com/c/jid", string(got))
+	assert.Equal(t, s.Installed[binary].Path, target)
+	assert.Len(t, s.Installed[binary].Previous, 2)
+	assert.Equal(t, "github.com/b/jid", s.Installed[binary].Previous[0].URL)
+	assert.Equal(t, "github.com/a/jid", s.Installed[binary].Previous[1].URL)
+}
+
+func TestInstallRemovesBinariesBeyondKeep(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", filepath.Join(dir, "bin"))()
+	p := installFrom(t, dir, "github.com/a/jid", 2)
+	binary := filepath.Join(dir, "bin", "jid")
+	s, _ := pkg.LoadState(p.StateFile)
+	first := s.Installed[binary].Path
+	installFrom(t, dir, "github.com/b/jid", 2)
+	installFrom(t, dir, "github.com/c/jid", 2)
+	s, _ = pkg.LoadState(p.StateFile)
+
+	assert.Len(t, s.Installed[binary].Previous, 1)
+	assert.Equal(t, "github.com/b/jid", s.Installed[binary].Previous[0].URL)
+	assert.False(t, fileExists(first))
+}
+
+func TestInstallSkipsStoreWithoutStateFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", filepath.Join(dir, "bin"))()
+	p := pkg.Packages{
+		Packages: []pkg.Package{{URL: "github.com/a/jid", Mode: pkg.ModeGOPATH}},
+		Runner:   &buildRunner{},
+		StoreDir: filepath.Join(dir, "store"),
+	}
+	err := p.Install(context.Background())
+	binary := filepath.Join(dir, "bin", "jid")
+	_, linkErr := os.Readlink(binary)
+
+	assert.NoError(t, err)
+	assert.True(t, fileExists(binary))
+	assert.Error(t, linkErr)
+	assert.False(t, fileExists(p.StoreDir))
+}
+
+func TestStateRollback(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", filepath.Join(dir, "bin"))()
+	p := installFrom(t, dir, "github.com/a/jid", 3)
+	installFrom(t, dir, "github.com/b/jid", 3)
+	binary := filepath.Join(dir, "bin", "jid")
+	s, _ := pkg.LoadState(p.StateFile)
+
+	installed, err := s.Rollback(binary)
+	got, _ := ioutil.ReadFile(binary)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/a/jid", installed.URL)
+	assert.Equal(t, "github.com/a/jid", string(got))
+	assert.Equal(t, "github.com/b/jid", s.Installed[binary].Previous[0].URL)
+
+	_, err = s.Rollback(binary)
+	got, _ = ioutil.ReadFile(binary)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/b/jid", string(got))
+}
+
+func TestStateRollbackReturnsErrorWithoutPreviousBinary(t *testing.T) {
+	s := &pkg.State{Installed: map[string]*pkg.Installed{
+		"/gobin/jid": {Binary: "/gobin/jid"},
+	}}
+	_, err := s.Rollback("/gobin/jid")
+
+	assert.Equal(t, "'/gobin/jid' has no previous binary to roll back to", err.Error())
+}
+
+func TestStateRollbackReturnsErrorWhenNotInstalled(t *testing.T) {
+	s := &pkg.State{Installed: map[string]*pkg.Installed{}}
+	_, err := s.Rollback("/gobin/jid")
+
+	assert.Equal(t, "'/gobin/jid' was not installed by gofile", err.Error())
+}
+
+// installFrom installs a fake jid built from the provided URL, keeping the
+// provided number of binaries in a store within dir.
+func installFrom(t *testing.T, dir string, url string, keep int) pkg.Packages {
+	p := pkg.Packages{
+		Packages:  []pkg.Package{{URL: url, Mode: pkg.ModeGOPATH}},
+		Runner:    &buildRunner{},
+		StateFile: filepath.Join(dir, "state.json"),
+		StoreDir:  filepath.Join(dir, "store"),
+		Keep:      keep,
+	}
+	err := p.Install(context.Background())
+	assert.NoError(t, err)
+
+	return p
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultToolsDir returns the directory caching the tools built by `Tool`,
+// `gofile/tools` within the user's cache directory.
+func DefaultToolsDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "gofile", "tools"), nil
+}
+
+// Tool returns the path of the binary of the package named by `name`, its
+// binary or URL, building it into `ToolsDir` unless already built, or
+// forced.  Tools are built from the gofile's pinned version without
+// touching the bin directory, and without recording, keeping, or running
+// the hooks of the package.
+func (p *Packages) Tool(ctx context.Context, name string) (string, error) {
+	steps, err := p.plan(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for i, step := range steps {
+		if toolName(step) != name && step.Name() != name {
+			continue
+		}
+
+		pkg := p.Packages[i]
+		pkg.PreInstall = nil
+		pkg.PostInstall = nil
+		pkg.Completion = ""
+
+		dir, err := p.toolPath(pkg, step)
+		if err != nil {
+			return "", err
+		}
+
+		binary := filepath.Join(dir, filepath.Base(step.Binary))
+		if _, err := os.Stat(binary); err == nil && !p.Force {
+			return binary, nil
+		}
+
+		tool := *p
+		tool.Packages = []Package{pkg}
+		tool.PreInstall = nil
+		tool.PostInstall = nil
+		tool.StateFile = ""
+		tool.StoreDir = ""
+		tool.CompletionDir = ""
+		tool.Force = true
+		tool.toolDir = dir
+		if err := tool.Install(ctx); err != nil {
+			return "", err
+		}
+
+		return binary, nil
+	}
+
+	return "", fmt.Errorf("'%s' is not a package of the gofile", name)
+}
+
+// toolPath returns the directory the provided package is built into, keyed
+// by the package and the toolchain building it, so changing either builds
+// the tool again.
+func (p *Packages) toolPath(pkg Package, step *Step) (string, error) {
+	dir := p.ToolsDir
+	if dir == "" {
+		var err error
+		if dir, err = DefaultToolsDir(); err != nil {
+			return "", err
+		}
+	}
+
+	// The dir becomes the GOBIN of the build, which must be absolute.
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(struct {
+		Package Package
+		Go      string
+		Mode    string
+	}{pkg, step.Go, step.Mode})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+
+	return filepath.Join(dir, toolName(step), hex.EncodeToString(sum[:])[:16]), nil
+}
+
+// toolName returns the name of the step's binary, without any extension.
+func toolName(step *Step) string {
+	return strings.TrimSuffix(filepath.Base(step.Binary), ".exe")
+}
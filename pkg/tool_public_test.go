@@ -0,0 +1,128 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/retr0h/gofile/pkg"
+	"github.com/retr0h/gofile/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// countRunner counts the commands built by its `buildRunner`.
+type countRunner struct {
+	buildRunner
+	count int
+}
+
+func (r *countRunner) Run(ctx context.Context, cmd *pkg.Command) error {
+	r.count++
+
+	return r.buildRunner.Run(ctx, cmd)
+}
+
+func TestToolBuildsIntoToolsDir(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	gobin := filepath.Join(dir, "bin")
+	defer setenv("GOBIN", gobin)()
+	r := &countRunner{}
+	p := pkg.Packages{
+		Packages: []pkg.Package{{
+			URL:         "github.com/simeji/jid/cmd/jid",
+			Mode:        pkg.ModeGOPATH,
+			PostInstall: []string{"jid --init"},
+		}},
+		Logger:    &utils.Logger{Level: utils.LevelError},
+		Runner:    r,
+		StateFile: filepath.Join(dir, "state.json"),
+		ToolsDir:  filepath.Join(dir, "tools"),
+	}
+	got, err := p.Tool(context.Background(), "jid")
+
+	assert.NoError(t, err)
+	assert.Regexp(t, "^"+filepath.Join(dir, "tools", "jid")+"/[0-9a-f]{16}/jid$", got)
+	assert.True(t, fileExists(got))
+	assert.False(t, fileExists(filepath.Join(gobin, "jid")))
+	assert.False(t, fileExists(p.StateFile))
+	assert.Equal(t, 1, r.count)
+}
+
+func TestToolReusesBuiltTool(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", filepath.Join(dir, "bin"))()
+	r := &countRunner{}
+	p := pkg.Packages{
+		Packages: []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Mode: pkg.ModeGOPATH}},
+		Logger:   &utils.Logger{Level: utils.LevelError},
+		Runner:   r,
+		ToolsDir: filepath.Join(dir, "tools"),
+	}
+	want, _ := p.Tool(context.Background(), "jid")
+	got, err := p.Tool(context.Background(), "github.com/simeji/jid/cmd/jid")
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, 1, r.count)
+
+	p.Force = true
+	_, err = p.Tool(context.Background(), "jid")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, r.count)
+}
+
+func TestToolBuildsIntoRelativeToolsDir(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	defer setenv("GOBIN", filepath.Join(dir, "bin"))()
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	r := &countRunner{}
+	p := pkg.Packages{
+		Packages: []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Mode: pkg.ModeGOPATH}},
+		Logger:   &utils.Logger{Level: utils.LevelError},
+		Runner:   r,
+		ToolsDir: ".tools",
+	}
+	got, err := p.Tool(context.Background(), "jid")
+
+	assert.NoError(t, err)
+	assert.True(t, filepath.IsAbs(got))
+	assert.True(t, fileExists(got))
+	assert.Equal(t, filepath.Dir(got), r.gobin)
+}
+
+func TestToolReturnsErrorWithUnknownPackage(t *testing.T) {
+	p := pkg.Packages{
+		Packages: []pkg.Package{{URL: "github.com/simeji/jid/cmd/jid", Mode: pkg.ModeGOPATH}},
+	}
+	_, err := p.Tool(context.Background(), "stringer")
+
+	assert.EqualError(t, err, "'stringer' is not a package of the gofile")
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Install modes of a package.
+const (
+	// ModeModule installs with `go install pkg@version`, supported by go1.16
+	// and later.
+	ModeModule = "module"
+	// ModeGOPATH installs with GOPATH-mode `go get`.
+	ModeGOPATH = "gopath"
+)
+
+var (
+	lookPath         = exec.LookPath
+	goVersionPattern = regexp.MustCompile(`go(\d+)\.(\d+)`)
+)
+
+// goVersion returns the major and minor version of the provided go
+// toolchain, as reported by `go version` run through the `Runner`.
+// Development builds report the release they are based on.
+func (p *Packages) goVersion(ctx context.Context, name string) (int, int, error) {
+	var out bytes.Buffer
+	cmd := &Command{Name: name, Args: []string{"version"}, Stdout: &out}
+	if err := p.runner().Run(ctx, cmd); err != nil {
+		return 0, 0, fmt.Errorf("unable to determine version of '%s': %s", name, err)
+	}
+
+	m := goVersionPattern.FindStringSubmatch(out.String())
+	if m == nil {
+		return 0, 0, fmt.Errorf("unable to parse version of '%s': %s", name, strings.TrimSpace(out.String()))
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+
+	return major, minor, nil
+}
+
+// detectMode returns the install mode supported by the provided go
+// toolchain.  GOPATH mode is used when modules are disabled through
+// GO111MODULE, or the toolchain predates `go install pkg@version`.
+func (p *Packages) detectMode(ctx context.Context, name string) (string, error) {
+	if os.Getenv("GO111MODULE") == "off" {
+		return ModeGOPATH, nil
+	}
+
+	major, minor, err := p.goVersion(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	if major > 1 || minor >= 16 {
+		return ModeModule, nil
+	}
+
+	return ModeGOPATH, nil
+}
+
+// resolveToolchain returns the path of the `go` binary described by the
+// provided toolchain, which is either a path to a `go` binary or a GOROOT, or
+// a version such as `go1.20` installed into ~/sdk (or on the PATH) with
+// golang.org/dl.  The `go` first on the PATH is used when empty.
+func resolveToolchain(toolchain string) (string, error) {
+	if toolchain == "" {
+		if _, err := lookPath("go"); err != nil {
+			return "", &ToolchainNotFoundError{Toolchain: "go", Searched: "$PATH"}
+		}
+
+		return "go", nil
+	}
+
+	if strings.HasPrefix(toolchain, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		toolchain = filepath.Join(home, toolchain[2:])
+	}
+
+	if strings.ContainsRune(toolchain, filepath.Separator) {
+		return resolveToolchainPath(toolchain)
+	}
+
+	return resolveToolchainVersion(toolchain)
+}
+
+// resolveToolchainPath returns the `go` binary of the provided GOROOT, or the
+// provided path when it is a binary.
+func resolveToolchainPath(path string) (string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", &ToolchainNotFoundError{Toolchain: path}
+	}
+
+	if fi.IsDir() {
+		return resolveToolchainPath(filepath.Join(path, "bin", "go"))
+	}
+
+	return path, nil
+}
+
+// resolveToolchainVersion returns the `go` binary of the provided version
+// from ~/sdk, falling back to the golang.org/dl wrapper on the PATH.
+func resolveToolchainVersion(version string) (string, error) {
+	if !strings.HasPrefix(version, "go") {
+		version = "go" + version
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		sdk := filepath.Join(home, "sdk", version, "bin", "go")
+		if _, err := os.Stat(sdk); err == nil {
+			return sdk, nil
+		}
+	}
+
+	if path, err := lookPath(version); err == nil {
+		return path, nil
+	}
+
+	return "", &ToolchainNotFoundError{Toolchain: version, Searched: "~/sdk/" + version + " or $PATH"}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoVersion(t *testing.T) {
+	p := &Packages{Runner: &versionRunner{out: "go version go1.15.2 linux/amd64\n"}}
+	major, minor, err := p.goVersion(context.Background(), "go")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, major)
+	assert.Equal(t, 15, minor)
+}
+
+func TestGoVersionDevel(t *testing.T) {
+	p := &Packages{Runner: &versionRunner{out: "go version devel go1.22-4b0bfb1 Tue Jan 2 00:00:00 2024 linux/amd64\n"}}
+	major, minor, err := p.goVersion(context.Background(), "go")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, major)
+	assert.Equal(t, 22, minor)
+}
+
+func TestGoVersionReturnsErrorWhenCommandFails(t *testing.T) {
+	p := &Packages{Runner: &versionRunner{err: errors.New("exec: \"go\": executable file not found in $PATH")}}
+	_, _, err := p.goVersion(context.Background(), "go")
+	want := "unable to determine version of 'go': exec: \"go\": executable file not found in $PATH"
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestGoVersionReturnsErrorWhenUnparsable(t *testing.T) {
+	p := &Packages{Runner: &versionRunner{out: "foo\n"}}
+	_, _, err := p.goVersion(context.Background(), "go")
+	want := "unable to parse version of 'go': foo"
+
+	assert.Equal(t, want, err.Error())
+}
+
+func TestDetectMode(t *testing.T) {
+	t.Setenv("GO111MODULE", "")
+
+	tests := map[string]string{
+		"go version go1.10.8 linux/amd64": ModeGOPATH,
+		"go version go1.15.2 linux/amd64": ModeGOPATH,
+		"go version go1.16 linux/amd64":   ModeModule,
+		"go version go1.21.0 linux/amd64": ModeModule,
+	}
+	for out, want := range tests {
+		p := &Packages{Runner: &versionRunner{out: out}}
+		got, err := p.detectMode(context.Background(), "go")
+
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, out)
+	}
+
+	t.Setenv("GO111MODULE", "off")
+	r := &versionRunner{out: "go version go1.21.0 linux/amd64"}
+	p := &Packages{Runner: r}
+	got, err := p.detectMode(context.Background(), "go")
+
+	assert.NoError(t, err)
+	assert.Equal(t, ModeGOPATH, got)
+	assert.Empty(t, r.commands)
+}
+
+// versionRunner writes the provided output of `go version` to the command's
+// stdout, and records the commands run.
+type versionRunner struct {
+	out      string
+	err      error
+	commands []*Command
+}
+
+func (r *versionRunner) Run(ctx context.Context, cmd *Command) error {
+	r.commands = append(r.commands, cmd)
+	if cmd.Stdout != nil {
+		io.WriteString(cmd.Stdout, r.out)
+	}
+
+	return r.err
+}
+
+func TestResolveToolchainDefault(t *testing.T) {
+	got, err := resolveToolchain("")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "go", got)
+}
+
+func TestResolveToolchainPath(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "bin"), 0755)
+	goBin := filepath.Join(dir, "bin", "go")
+	ioutil.WriteFile(goBin, []byte(""), 0755)
+
+	got, err := resolveToolchain(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, goBin, got)
+
+	got, err = resolveToolchain(goBin)
+	assert.NoError(t, err)
+	assert.Equal(t, goBin, got)
+}
+
+func TestResolveToolchainVersionFromSDK(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	t.Setenv("HOME", dir)
+	os.MkdirAll(filepath.Join(dir, "sdk", "go1.20", "bin"), 0755)
+	goBin := filepath.Join(dir, "sdk", "go1.20", "bin", "go")
+	ioutil.WriteFile(goBin, []byte(""), 0755)
+
+	for _, toolchain := range []string{"go1.20", "1.20", "~/sdk/go1.20"} {
+		got, err := resolveToolchain(toolchain)
+
+		assert.NoError(t, err)
+		assert.Equal(t, goBin, got, toolchain)
+	}
+}
+
+func TestResolveToolchainDefaultReturnsErrorWhenNotFound(t *testing.T) {
+	original := lookPath
+	lookPath = func(file string) (string, error) {
+		return "", errors.New("not found")
+	}
+	defer func() { lookPath = original }()
+
+	_, err := resolveToolchain("")
+
+	var notFound *ToolchainNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "toolchain 'go' not found in $PATH", err.Error())
+}
+
+func TestResolveToolchainVersionFromPath(t *testing.T) {
+	t.Setenv("HOME", "/nonexistent")
+	original := lookPath
+	lookPath = func(file string) (string, error) {
+		return "/usr/local/bin/" + file, nil
+	}
+	defer func() { lookPath = original }()
+
+	got, err := resolveToolchain("go1.20")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/usr/local/bin/go1.20", got)
+}
+
+func TestResolveToolchainReturnsErrorWhenNotFound(t *testing.T) {
+	t.Setenv("HOME", "/nonexistent")
+	original := lookPath
+	lookPath = func(file string) (string, error) {
+		return "", errors.New("not found")
+	}
+	defer func() { lookPath = original }()
+
+	_, err := resolveToolchain("go1.20")
+	want := "toolchain 'go1.20' not found in ~/sdk/go1.20 or $PATH"
+
+	assert.Equal(t, want, err.Error())
+}
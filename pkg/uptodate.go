@@ -0,0 +1,94 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// upToDate returns true when the step's installed binary already satisfies
+// the step, which is known without building or downloading anything when the
+// step pins a version, a checksum, or a release.  Unpinned packages and local
+// checkouts are never up to date, as they may have changed since installed.
+func (p *Packages) upToDate(ctx context.Context, step *Step, state *State) bool {
+	if _, err := os.Stat(step.Binary); err != nil {
+		return false
+	}
+
+	sum, err := hashFile(step.Binary)
+	if err != nil {
+		return false
+	}
+
+	// Binaries modified since they were installed are reinstalled.
+	installed, recorded := state.Installed[step.Binary]
+	if recorded && installed.SHA256 != sum {
+		return false
+	}
+
+	if step.Release != nil {
+		return recorded && installed.URL == step.Release.URL
+	}
+
+	if step.Path != "" {
+		return false
+	}
+
+	if step.SHA256 != "" {
+		return strings.EqualFold(sum, step.SHA256) && p.builtWith(ctx, step)
+	}
+
+	if step.Mode != ModeModule || step.Version == "" || step.Version == "latest" {
+		return false
+	}
+
+	info, err := readBuildInfo(step.Binary)
+	if err != nil {
+		return false
+	}
+
+	return info.Path == step.URL && info.Main.Version == step.Version && p.builtWith(ctx, step)
+}
+
+// builtWith returns true when the step's installed binary was built by the
+// major and minor release of the step's toolchain, or when the step uses the
+// `go` on the PATH.
+func (p *Packages) builtWith(ctx context.Context, step *Step) bool {
+	if step.Go == "" {
+		return true
+	}
+
+	info, err := readBuildInfo(step.Binary)
+	if err != nil {
+		return false
+	}
+
+	major, minor, err := p.goVersion(ctx, step.Go)
+	if err != nil {
+		return false
+	}
+	m := goVersionPattern.FindStringSubmatch(info.GoVersion)
+
+	return m != nil && fmt.Sprintf("%s.%s", m[1], m[2]) == fmt.Sprintf("%d.%d", major, minor)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpToDate(t *testing.T) {
+	defer stubReadBuildInfo(jidSum)()
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	binary := filepath.Join(dir, "jid")
+	ioutil.WriteFile(binary, []byte("github.com/simeji/jid/cmd/jid"), 0755)
+	sum := "a8f7cd6a1e16e21aa52b61f7047127785867bbb982d441ceef2005355bec94b0"
+	p := Packages{}
+
+	var tests = []struct {
+		step  *Step
+		state *State
+		want  bool
+	}{
+		{&Step{URL: "github.com/simeji/jid/cmd/jid", Version: "v0.7.6", Mode: ModeModule, Binary: binary}, &State{}, true},
+		{&Step{URL: "github.com/simeji/jid/cmd/jid", Version: "v0.7.5", Mode: ModeModule, Binary: binary}, &State{}, false},
+		{&Step{URL: "github.com/simeji/jid/cmd/jid", Mode: ModeModule, Binary: binary}, &State{}, false},
+		{&Step{URL: "github.com/simeji/jid/cmd/jid", Version: "latest", Mode: ModeModule, Binary: binary}, &State{}, false},
+		{&Step{URL: "github.com/simeji/jid/cmd/jid", Mode: ModeGOPATH, Binary: binary}, &State{}, false},
+		{&Step{URL: "github.com/simeji/jid/cmd/jid", Mode: ModeGOPATH, SHA256: sum, Binary: binary}, &State{}, true},
+		{&Step{URL: "github.com/simeji/jid/cmd/jid", Mode: ModeGOPATH, SHA256: strings.ToUpper(sum), Binary: binary}, &State{}, true},
+		{&Step{Path: "/src/jid", Binary: binary}, &State{}, false},
+		{&Step{URL: "github.com/simeji/jid/cmd/jid", Version: "v0.7.6", Mode: ModeModule, Binary: filepath.Join(dir, "missing")}, &State{}, false},
+		{
+			&Step{URL: "github.com/simeji/jid/cmd/jid", Version: "v0.7.6", Mode: ModeModule, Binary: binary},
+			&State{Installed: map[string]*Installed{binary: {SHA256: "0000"}}},
+			false,
+		},
+		{
+			&Step{Release: &Release{URL: "https://example.com/v0.7.6/jid.zip"}, Binary: binary},
+			&State{Installed: map[string]*Installed{binary: {URL: "https://example.com/v0.7.6/jid.zip", SHA256: sum}}},
+			true,
+		},
+		{
+			&Step{Release: &Release{URL: "https://example.com/v0.7.7/jid.zip"}, Binary: binary},
+			&State{Installed: map[string]*Installed{binary: {URL: "https://example.com/v0.7.6/jid.zip", SHA256: sum}}},
+			false,
+		},
+		{&Step{Release: &Release{URL: "https://example.com/v0.7.6/jid.zip"}, Binary: binary}, &State{}, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, p.upToDate(context.Background(), tt.step, tt.state), tt.step.Name())
+	}
+}
+
+func TestUpToDateWithToolchain(t *testing.T) {
+	defer stubReadBuildInfo(jidSum)()
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	binary := filepath.Join(dir, "jid")
+	ioutil.WriteFile(binary, nil, 0755)
+	step := &Step{URL: "github.com/simeji/jid/cmd/jid", Version: "v0.7.6", Mode: ModeModule, Go: "/sdk/go1.21.0/bin/go", Binary: binary}
+	p := Packages{Runner: &versionRunner{out: "go version go1.21.0 linux/amd64"}}
+
+	assert.False(t, p.upToDate(context.Background(), step, &State{}))
+}
+
+func TestUpToDateWithSameToolchainRelease(t *testing.T) {
+	defer stubReadBuildInfo(jidSum)()
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	binary := filepath.Join(dir, "jid")
+	ioutil.WriteFile(binary, nil, 0755)
+	step := &Step{URL: "github.com/simeji/jid/cmd/jid", Version: "v0.7.6", Mode: ModeModule, Go: "/sdk/go1.20.5/bin/go", Binary: binary}
+	p := Packages{Runner: &versionRunner{out: "go version go1.20.5 linux/amd64"}}
+
+	assert.True(t, p.upToDate(context.Background(), step, &State{}))
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package pkg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gofile")
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "jid")
+	ioutil.WriteFile(filename, []byte("foo"), 0644)
+
+	got, err := hashFile(filename)
+	want := "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.NoError(t, verifyFile(filename, want))
+}
+
+func TestHashFileReturnsErrorWithMissingFile(t *testing.T) {
+	_, err := hashFile("/missing/jid")
+
+	assert.Error(t, err)
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	assert.NoError(t, verifyChecksum("jid", "ABC", "abc"))
+
+	err := verifyChecksum("jid", "abc", "def")
+	want := "checksum mismatch for 'jid': expected abc, got def"
+
+	assert.Equal(t, want, err.Error())
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level of a log message, each level includes the levels before it.
+type Level int
+
+// Levels of log messages, from the least to the most verbose.
+const (
+	LevelError Level = iota
+	LevelWarn
+	LevelInfo
+	LevelDebug
+	LevelTrace
+)
+
+var levelNames = []string{"error", "warn", "info", "debug", "trace"}
+
+// colorPattern matches the escape sequences of colours, which are stripped
+// from the log file.
+var colorPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// String returns the name of the level.
+func (l Level) String() string {
+	if l < LevelError || l > LevelTrace {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+
+	return levelNames[l]
+}
+
+// ParseLevel returns the level with the provided name.
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return Level(i), nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("unknown log level '%s'", name)
+}
+
+var (
+	// Log is the logger shared by the cli, configured from its flags.
+	Log = &Logger{Level: LevelInfo}
+)
+
+// Logger writing messages up to its level, errors and warnings to `Err` and
+// everything else to `Out`.  Every message is also written to `File`
+// regardless of the level, so runs can be debugged after the fact.
+type Logger struct {
+	Level Level     // Level of the messages written to `Out` and `Err`.
+	Out   io.Writer // Out receiving messages, os.Stdout when nil.
+	Err   io.Writer // Err receiving errors and warnings, os.Stderr when nil.
+	File  io.Writer // File receiving every message with a timestamp and level, none when nil.
+	mu    sync.Mutex
+}
+
+// WithOut returns a logger like this logger, which writes to out rather
+// than `Out`.
+func (l *Logger) WithOut(out io.Writer) *Logger {
+	return &Logger{Level: l.Level, Out: out, Err: l.Err, File: l.File}
+}
+
+// Enabled returns true when messages of the provided level are written
+// anywhere.
+func (l *Logger) Enabled(level Level) bool {
+	return level <= l.Level || l.File != nil
+}
+
+// Errorf logs a message at the error level.
+func (l *Logger) Errorf(format string, a ...interface{}) {
+	l.Logf(LevelError, format, a...)
+}
+
+// Warnf logs a message at the warn level.
+func (l *Logger) Warnf(format string, a ...interface{}) {
+	l.Logf(LevelWarn, format, a...)
+}
+
+// Infof logs a message at the info level.
+func (l *Logger) Infof(format string, a ...interface{}) {
+	l.Logf(LevelInfo, format, a...)
+}
+
+// Debugf logs a message at the debug level.
+func (l *Logger) Debugf(format string, a ...interface{}) {
+	l.Logf(LevelDebug, format, a...)
+}
+
+// Tracef logs a message at the trace level.
+func (l *Logger) Tracef(format string, a ...interface{}) {
+	l.Logf(LevelTrace, format, a...)
+}
+
+// Logf logs a message at the provided level.
+func (l *Logger) Logf(level Level, format string, a ...interface{}) {
+	l.write(level, []byte(fmt.Sprintf(format, a...)), l.console(level))
+}
+
+// Writer returns a writer logging everything written to it at the provided
+// level, or nil when the level is not enabled.
+func (l *Logger) Writer(level Level) io.Writer {
+	return l.writer(level, false)
+}
+
+// ErrWriter returns a writer logging everything written to it at the
+// provided level, which writes to `Err` rather than `Out` whatever the level,
+// or nil when the level is not enabled.
+func (l *Logger) ErrWriter(level Level) io.Writer {
+	return l.writer(level, true)
+}
+
+func (l *Logger) writer(level Level, stderr bool) io.Writer {
+	if !l.Enabled(level) {
+		return nil
+	}
+
+	return writerFunc(func(p []byte) (int, error) {
+		console := l.console(level)
+		if stderr {
+			console = l.console(LevelError)
+		}
+		l.write(level, p, console)
+
+		return len(p), nil
+	})
+}
+
+// write writes the message to the console when the level is enabled, and to
+// the file with the time and level prefixing each line.
+func (l *Logger) write(level Level, msg []byte, console io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if level <= l.Level {
+		console.Write(msg)
+	}
+
+	if l.File != nil {
+		prefix := fmt.Sprintf("%s %-5s ", time.Now().UTC().Format(time.RFC3339), strings.ToUpper(level.String()))
+		for _, line := range bytes.SplitAfter(msg, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			io.WriteString(l.File, prefix)
+			l.File.Write(colorPattern.ReplaceAll(line, nil))
+			if line[len(line)-1] != '\n' {
+				io.WriteString(l.File, "\n")
+			}
+		}
+	}
+}
+
+// console returns the writer receiving messages of the provided level,
+// resolved on each write so redirecting os.Stdout or os.Stderr is honoured.
+func (l *Logger) console(level Level) io.Writer {
+	if level <= LevelWarn {
+		if l.Err != nil {
+			return l.Err
+		}
+
+		return os.Stderr
+	}
+
+	if l.Out != nil {
+		return l.Out
+	}
+
+	return os.Stdout
+}
+
+// writerFunc adapts a function to an io.Writer.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package utils_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/retr0h/gofile/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLevel(t *testing.T) {
+	got, err := utils.ParseLevel("DEBUG")
+
+	assert.NoError(t, err)
+	assert.Equal(t, utils.LevelDebug, got)
+}
+
+func TestParseLevelReturnsErrorWithUnknownLevel(t *testing.T) {
+	_, err := utils.ParseLevel("verbose")
+
+	assert.Equal(t, "unknown log level 'verbose'", err.Error())
+}
+
+func TestLoggerWritesUpToLevel(t *testing.T) {
+	var out, errOut bytes.Buffer
+	l := &utils.Logger{Level: utils.LevelInfo, Out: &out, Err: &errOut}
+	l.Errorf("error\n")
+	l.Warnf("warn\n")
+	l.Infof("info\n")
+	l.Debugf("debug\n")
+	l.Tracef("trace\n")
+
+	assert.Equal(t, "info\n", out.String())
+	assert.Equal(t, "error\nwarn\n", errOut.String())
+}
+
+func TestLoggerWritesEveryLevelToFile(t *testing.T) {
+	var out, file bytes.Buffer
+	l := &utils.Logger{Level: utils.LevelError, Out: &out, File: &file}
+	l.Infof("Installing: %s\n", aurora.Cyan("jid"))
+	l.Tracef("first\nsecond")
+
+	assert.Empty(t, out.String())
+	assert.Regexp(t, `^\S+ INFO  Installing: jid
+\S+ TRACE first
+\S+ TRACE second
+$`, file.String())
+}
+
+func TestLoggerWriter(t *testing.T) {
+	var out, errOut bytes.Buffer
+	l := &utils.Logger{Level: utils.LevelTrace, Out: &out, Err: &errOut}
+	l.Writer(utils.LevelTrace).Write([]byte("stdout"))
+	l.ErrWriter(utils.LevelTrace).Write([]byte("stderr"))
+
+	assert.Equal(t, "stdout", out.String())
+	assert.Equal(t, "stderr", errOut.String())
+}
+
+func TestLoggerWriterIsNilWhenLevelDisabled(t *testing.T) {
+	l := &utils.Logger{Level: utils.LevelInfo}
+
+	assert.Nil(t, l.Writer(utils.LevelTrace))
+	assert.Nil(t, l.ErrWriter(utils.LevelTrace))
+}
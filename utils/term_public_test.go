@@ -0,0 +1,58 @@
+// Copyright (c) 2018 John Dewey
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+
+package utils_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/retr0h/gofile/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTerminal(t *testing.T) {
+	f, _ := ioutil.TempFile("", "gofile")
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	assert.False(t, utils.IsTerminal(&bytes.Buffer{}))
+	assert.False(t, utils.IsTerminal(f))
+}
+
+func TestColorEnabledHonoursNoColor(t *testing.T) {
+	os.Setenv("NO_COLOR", "")
+	defer os.Unsetenv("NO_COLOR")
+
+	assert.False(t, utils.ColorEnabled(os.Stdout))
+}
+
+func TestSetColor(t *testing.T) {
+	defer utils.SetColor(false)
+	utils.SetColor(true)
+
+	assert.Equal(t, "\x1b[36mjid\x1b[0m", utils.Color.Cyan("jid").String())
+
+	utils.SetColor(false)
+
+	assert.Equal(t, "jid", utils.Color.Cyan("jid").String())
+}
@@ -21,10 +21,7 @@
 package utils
 
 import (
-	"fmt"
 	"os"
-
-	"github.com/logrusorgru/aurora"
 )
 
 var (
@@ -34,7 +31,7 @@ var (
 
 // PrintError formats and prints the provided string for error messages.
 func PrintError(msg string) {
-	fmt.Fprintf(os.Stderr, "%s: %s\n", aurora.Red("ERROR"), msg)
+	Log.Errorf("%s: %s\n", Color.Red("ERROR"), msg)
 }
 
 // PrintErrorAndExit prints the error message and os.Exits with the optionally
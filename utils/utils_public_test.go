@@ -32,7 +32,7 @@ func TestPrintError(t *testing.T) {
 	got := capturer.CaptureStderr(func() {
 		utils.PrintError("foo")
 	})
-	want := "\x1b[31mERROR\x1b[0m: foo\n"
+	want := "ERROR: foo\n"
 
 	assert.Equal(t, want, got)
 }
@@ -52,7 +52,7 @@ func TestPrintErrorAndExit(t *testing.T) {
 		utils.PrintErrorAndExit("foo")
 	})
 
-	assert.Equal(t, "\x1b[31mERROR\x1b[0m: foo\n", out)
+	assert.Equal(t, "ERROR: foo\n", out)
 	assert.Equal(t, 1, got)
 
 	utils.PrintErrorAndExit("foo", 5)